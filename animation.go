@@ -1,15 +1,34 @@
 package tentsuyu
 
-//Animation is used to control animation frames
+import (
+	"math"
+	"time"
+)
+
+//animationTick is a tick of a 60 TPS game rounded up to the nanosecond, so a frame of a whole number of
+//milliseconds, such as 50, lasts as many ticks as it should and not one more
+const animationTick = (time.Second + referenceTPS - 1) / referenceTPS
+
+//Animation is used to control animation frames.
+//It advances by elapsed time, each frame is shown for its duration set with SetFrameDurations,
+//or for the frame speed in ticks of a 60 TPS game, scaled by the playback rate.
 type Animation struct {
-	currFrame, frameCount, frameSpeed int
-	paused                            bool
-	ImageParts                        *BasicImageParts
-	SpriteSheet                       *SpriteSheet
-	Frames                            []int
-	LoopCompleted                     bool
-	reverse                           bool
-	Repeating                         bool
+	currFrame, frameSpeed int
+	paused                bool
+	ImageParts            *BasicImageParts
+	SpriteSheet           *SpriteSheet
+	Frames                []int
+	LoopCompleted         bool
+	reverse               bool
+	Repeating             bool
+	durations             []int
+	pingPong              bool
+	loops                 int
+	step                  int
+	elapsed               time.Duration
+	rate                  float64
+	finished              bool
+	advanced              int
 }
 
 //NewAnimation takes a spritesheet, []int of frames to play, and speed to return an Animation
@@ -26,6 +45,7 @@ func NewAnimation(spriteSheet *SpriteSheet, frames []int, speed int) *Animation
 		frameSpeed: speed,
 		paused:     false,
 		Repeating:  true,
+		rate:       1,
 	}
 
 	return a
@@ -36,64 +56,210 @@ func (a *Animation) SetAnimationSpeed(s int) {
 	a.frameSpeed = s
 }
 
-//SetReverse tells the animation to play in reverse
+//SetReverse tells the animation to play in reverse, from the last frame to the first.
+//The current frame is kept, call Reset to start again from the last frame.
 func (a *Animation) SetReverse() {
-	a.reverse = true
+	a.setReverse(true)
 }
 
 //SetForward tells the animation to play normally.
 //This should only be necessary after calling SetReverse()
 func (a *Animation) SetForward() {
-	a.reverse = false
+	a.setReverse(false)
+}
+
+//IsReverse returns true if the animation plays from the last frame to the first
+func (a Animation) IsReverse() bool {
+	return a.reverse
+}
+
+func (a *Animation) setReverse(reverse bool) {
+	if a.reverse == reverse {
+		return
+	}
+	a.reverse = reverse
+	a.step = a.stepOf(a.currFrame)
+}
+
+//SetPingPong tells the animation to play forward to the last frame and back again,
+//or back to the first frame and forward again when reversed.
+//A loop is completed each time it is back at the frame it started from.
+func (a *Animation) SetPingPong(pingPong bool) {
+	if a.pingPong == pingPong {
+		return
+	}
+	a.pingPong = pingPong
+	a.step = a.stepOf(a.currFrame)
 }
 
-//Update the animation if not paused
+//IsPingPong returns true if the animation plays forward and back again
+func (a Animation) IsPingPong() bool {
+	return a.pingPong
+}
+
+//SetPlaybackRate multiplies the speed the animation plays at, 2 plays twice as fast and 0.5 at half speed.
+//Negative rates are treated as 0, use SetReverse to play backwards.
+func (a *Animation) SetPlaybackRate(rate float64) {
+	if rate < 0 {
+		rate = 0
+	}
+	a.rate = rate
+}
+
+//PlaybackRate returns the speed multiplier of the animation, 1 by default
+func (a Animation) PlaybackRate() float64 {
+	return a.rate
+}
+
+//Update advances the animation by a tick of a 60 TPS game if not paused
 func (a *Animation) Update() {
-	if !a.paused {
-		if !a.reverse {
-			a.frameCount++
-			if a.frameCount > a.frameSpeed {
-				a.currFrame++
-				if a.currFrame >= len(a.Frames) {
-					a.currFrame = 0
-					a.LoopCompleted = true
-				} else {
-					if a.LoopCompleted == true {
-						a.LoopCompleted = false
-					}
-				}
-				a.frameCount = 0
-				a.ImageParts.Sx = a.SpriteSheet.Frames[a.Frames[a.currFrame]].Frame["x"]
-				a.ImageParts.Sy = a.SpriteSheet.Frames[a.Frames[a.currFrame]].Frame["y"]
-				a.ImageParts.Width = a.SpriteSheet.Frames[a.Frames[a.currFrame]].Frame["w"]
-				a.ImageParts.Height = a.SpriteSheet.Frames[a.Frames[a.currFrame]].Frame["h"]
-			}
+	a.advance(time.Duration(math.Round(float64(animationTick) * a.rate)))
+}
+
+//UpdateDelta advances the animation by dt seconds instead of a single tick.
+//Pass Clock.SubsystemDelta(ClockAnimation) to have the animation follow the game's time scale.
+func (a *Animation) UpdateDelta(dt float64) {
+	a.advance(time.Duration(math.Round(dt * a.rate * float64(time.Second))))
+}
+
+//advance moves the animation on by d, through as many frames and loops as it covers.
+//A repeating animation wraps back to its first step, any other stops on its last step.
+//Every step taken is counted in advanced so the Animator can fire the events of frames it passed.
+func (a *Animation) advance(d time.Duration) {
+	if a.paused || len(a.Frames) == 0 {
+		return
+	}
+	steps := a.sequenceLen()
+	if a.step >= steps {
+		a.step = 0
+	}
+	a.elapsed += d
+	for a.elapsed >= a.frameDuration(a.currFrame) {
+		a.elapsed -= a.frameDuration(a.currFrame)
+		if a.step+1 < steps {
+			a.step++
+			a.LoopCompleted = false
 		} else {
-			a.frameCount++
-			if a.frameCount > a.frameSpeed {
-				a.currFrame--
-				if a.currFrame <= 0 {
-					a.currFrame = len(a.Frames) - 1
-					a.LoopCompleted = true
-				} else {
-					if a.LoopCompleted == true {
-						a.LoopCompleted = false
-					}
-				}
-				a.frameCount = 0
-				a.ImageParts.Sx = a.SpriteSheet.Frames[a.Frames[a.currFrame]].Frame["x"]
-				a.ImageParts.Sy = a.SpriteSheet.Frames[a.Frames[a.currFrame]].Frame["y"]
-				a.ImageParts.Width = a.SpriteSheet.Frames[a.Frames[a.currFrame]].Frame["w"]
-				a.ImageParts.Height = a.SpriteSheet.Frames[a.Frames[a.currFrame]].Frame["h"]
+			a.loops++
+			a.LoopCompleted = true
+			if !a.Repeating {
+				a.elapsed = 0
+				a.paused = true
+				a.finished = true
+				return
 			}
+			a.step = 0
 		}
+		a.advanced++
+		a.setFrame(a.sequenceAt(a.step))
 	}
+}
 
-	if !a.Repeating && !a.paused {
-		if a.LoopCompleted {
-			a.Stop()
+//sequenceLen returns the number of steps in a loop of the animation
+func (a *Animation) sequenceLen() int {
+	n := len(a.Frames)
+	if a.pingPong && n > 1 {
+		return 2*n - 2
+	}
+	return n
+}
+
+//sequenceAt returns the position in Frames shown at the step of a loop
+func (a *Animation) sequenceAt(step int) int {
+	n := len(a.Frames)
+	i := step
+	if a.pingPong && i >= n {
+		i = 2*n - 2 - i
+	}
+	if a.reverse {
+		i = n - 1 - i
+	}
+	return i
+}
+
+//stepOf returns the first step of a loop showing the position in Frames
+func (a *Animation) stepOf(frame int) int {
+	for step := 0; step < a.sequenceLen(); step++ {
+		if a.sequenceAt(step) == frame {
+			return step
 		}
 	}
+	return 0
+}
+
+//frameDuration returns how long the position in Frames is shown for,
+//at least a millisecond for a duration and a tick for the frame speed
+func (a *Animation) frameDuration(frame int) time.Duration {
+	if frame < len(a.durations) {
+		if a.durations[frame] < 1 {
+			return time.Millisecond
+		}
+		return time.Duration(a.durations[frame]) * time.Millisecond
+	}
+	if a.frameSpeed < 0 {
+		return animationTick
+	}
+	return time.Duration(a.frameSpeed+1) * animationTick
+}
+
+//setFrame shows the position in Frames
+func (a *Animation) setFrame(frame int) {
+	a.currFrame = frame
+	a.ImageParts.Sx = a.SpriteSheet.Frames[a.Frames[a.currFrame]].Frame["x"]
+	a.ImageParts.Sy = a.SpriteSheet.Frames[a.Frames[a.currFrame]].Frame["y"]
+	a.ImageParts.Width = a.SpriteSheet.Frames[a.Frames[a.currFrame]].Frame["w"]
+	a.ImageParts.Height = a.SpriteSheet.Frames[a.Frames[a.currFrame]].Frame["h"]
+}
+
+//Duration returns how long a loop of the animation lasts at a playback rate of 1
+func (a *Animation) Duration() time.Duration {
+	var total time.Duration
+	for step := 0; step < a.sequenceLen(); step++ {
+		total += a.frameDuration(a.sequenceAt(step))
+	}
+	return total
+}
+
+//Time returns how far the animation is into its current loop at a playback rate of 1
+func (a *Animation) Time() time.Duration {
+	t := a.elapsed
+	for step := 0; step < a.step; step++ {
+		t += a.frameDuration(a.sequenceAt(step))
+	}
+	return t
+}
+
+//Seek moves the animation to the time into a loop, wrapping times past the end of a loop for repeating animations
+//and stopping on the last frame for the others
+func (a *Animation) Seek(t time.Duration) {
+	if len(a.Frames) == 0 {
+		return
+	}
+	total := a.Duration()
+	if t < 0 {
+		t = 0
+	}
+	if t >= total {
+		if a.Repeating {
+			t %= total
+		} else {
+			t = total - 1
+		}
+	}
+	a.step = 0
+	a.elapsed = 0
+	a.finished = false
+	a.LoopCompleted = false
+	for step := 0; step < a.sequenceLen(); step++ {
+		d := a.frameDuration(a.sequenceAt(step))
+		if t < d {
+			a.step = step
+			a.elapsed = t
+			break
+		}
+		t -= d
+	}
+	a.setFrame(a.sequenceAt(a.step))
 }
 
 //ReturnImageParts of the current animation
@@ -106,6 +272,17 @@ func (a *Animation) SetFrameSpeed(speed int) {
 	a.frameSpeed = speed
 }
 
+//SetFrameDurations sets how long each entry of Frames is shown in milliseconds, replacing the frame speed.
+//Passing nil goes back to the frame speed.
+func (a *Animation) SetFrameDurations(durations []int) {
+	a.durations = durations
+}
+
+//FrameDurations returns the duration of each entry of Frames in milliseconds, nil if the frame speed is used
+func (a *Animation) FrameDurations() []int {
+	return a.durations
+}
+
 //CurrentFrame returns the current frame of the animation
 func (a Animation) CurrentFrame() int {
 	return a.currFrame
@@ -113,12 +290,10 @@ func (a Animation) CurrentFrame() int {
 
 //SetCurrentFrame of the current animation
 func (a *Animation) SetCurrentFrame(frame int) {
-	a.currFrame = frame
-	a.frameCount = 0
-	a.ImageParts.Sx = a.SpriteSheet.Frames[a.Frames[a.currFrame]].Frame["x"]
-	a.ImageParts.Sy = a.SpriteSheet.Frames[a.Frames[a.currFrame]].Frame["y"]
-	a.ImageParts.Width = a.SpriteSheet.Frames[a.Frames[a.currFrame]].Frame["w"]
-	a.ImageParts.Height = a.SpriteSheet.Frames[a.Frames[a.currFrame]].Frame["h"]
+	a.step = a.stepOf(frame)
+	a.elapsed = 0
+	a.finished = false
+	a.setFrame(frame)
 	a.LoopCompleted = false
 }
 
@@ -127,21 +302,28 @@ func (a *Animation) Pause() {
 	a.paused = true
 }
 
-//Reset the animation to the starting point
+//Reset the animation to the starting point, the last frame when reversed
 func (a *Animation) Reset() {
 	a.paused = false
 	a.LoopCompleted = false
-	a.frameCount = 0
-	a.currFrame = 0
+	a.finished = false
+	a.step = 0
+	a.elapsed = 0
+	if len(a.Frames) > 0 {
+		a.setFrame(a.sequenceAt(0))
+	}
 }
 
-//Resume playing the animation
+//Resume playing the animation, from the start if it had finished
 func (a *Animation) Resume() {
-	a.paused = false
+	a.Play()
 }
 
-//Play the animation
+//Play the animation, from the start if it had finished
 func (a *Animation) Play() {
+	if a.finished {
+		a.Reset()
+	}
 	a.paused = false
 }
 
@@ -150,10 +332,13 @@ func (a Animation) IsPaused() bool {
 	return a.paused
 }
 
-//Stop the animation
+//IsFinished returns true if an animation that does not repeat has played to its end
+func (a Animation) IsFinished() bool {
+	return a.finished
+}
+
+//Stop the animation and go back to the starting point
 func (a *Animation) Stop() {
+	a.Reset()
 	a.paused = true
-	a.LoopCompleted = false
-	a.frameCount = 0
-	a.currFrame = 0
 }
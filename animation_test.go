@@ -0,0 +1,260 @@
+package tentsuyu
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+//testSheet is a sprite sheet of four 16x16 frames in a row
+func testSheet() *SpriteSheet {
+	return NewSpriteSheet(64, 16, 16, 16, 0, 0)
+}
+
+//playFrames returns the current frame followed by the frame after each of n updates
+func playFrames(a *Animation, n int) []int {
+	frames := []int{a.CurrentFrame()}
+	for i := 0; i < n; i++ {
+		a.Update()
+		frames = append(frames, a.CurrentFrame())
+	}
+	return frames
+}
+
+//ticksPerFrame returns how many updates each frame is shown for over n updates
+func ticksPerFrame(a *Animation, n int) []int {
+	counts := []int{}
+	frame, count := a.CurrentFrame(), 0
+	for i := 0; i < n; i++ {
+		a.Update()
+		count++
+		if a.CurrentFrame() != frame {
+			counts = append(counts, count)
+			frame, count = a.CurrentFrame(), 0
+		}
+	}
+	return counts
+}
+
+func TestAnimationWrapOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		reverse  bool
+		pingPong bool
+		want     []int
+	}{
+		{"forward", false, false, []int{0, 1, 2, 3, 0, 1, 2, 3, 0}},
+		{"reverse", true, false, []int{3, 2, 1, 0, 3, 2, 1, 0, 3}},
+		{"ping-pong", false, true, []int{0, 1, 2, 3, 2, 1, 0, 1, 2}},
+		{"reverse ping-pong", true, true, []int{3, 2, 1, 0, 1, 2, 3, 2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAnimation(testSheet(), []int{0, 1, 2, 3}, 0)
+			if tt.reverse {
+				a.SetReverse()
+			}
+			a.SetPingPong(tt.pingPong)
+			a.Reset()
+			if got := playFrames(a, len(tt.want)-1); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("frames %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnimationReverseShowsFirstFrame(t *testing.T) {
+	a := NewAnimation(testSheet(), []int{0, 1, 2}, 2)
+	a.SetReverse()
+	a.Reset()
+	shown := map[int]int{}
+	for i := 0; i < 9; i++ {
+		shown[a.CurrentFrame()]++
+		a.Update()
+	}
+	want := map[int]int{0: 3, 1: 3, 2: 3}
+	if !reflect.DeepEqual(shown, want) {
+		t.Errorf("ticks shown per frame %v, want %v", shown, want)
+	}
+	if a.ImageParts.Sx != 32 {
+		t.Errorf("image parts at x %d after a full loop, want 32", a.ImageParts.Sx)
+	}
+}
+
+func TestAnimationFrameDurations(t *testing.T) {
+	tests := []struct {
+		name       string
+		speed      int
+		durations  []int
+		wantTicks  []int
+		wantLength time.Duration
+	}{
+		{"frame speed", 1, nil, []int{2, 2, 2}, 6 * animationTick},
+		{"durations", 0, []int{50, 100, 17}, []int{3, 6, 2}, 167 * time.Millisecond},
+		{"zero duration", 0, []int{0, 0, 0}, []int{1, 1, 1}, 3 * time.Millisecond},
+		{"negative frame speed", -5, nil, []int{1, 1, 1}, 3 * animationTick},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAnimation(testSheet(), []int{0, 1, 2}, tt.speed)
+			a.SetFrameDurations(tt.durations)
+			if got := ticksPerFrame(a, 11); !reflect.DeepEqual(got[:3], tt.wantTicks) {
+				t.Errorf("ticks per frame %v, want %v", got, tt.wantTicks)
+			}
+			if got := a.Duration(); got != tt.wantLength {
+				t.Errorf("Duration() = %v, want %v", got, tt.wantLength)
+			}
+		})
+	}
+}
+
+func TestAnimationPlaybackRate(t *testing.T) {
+	tests := []struct {
+		name  string
+		speed int
+		rate  float64
+		want  []int
+	}{
+		{"normal", 1, 1, []int{0, 0, 1, 1, 2}},
+		{"double", 1, 2, []int{0, 1, 2, 3, 0}},
+		{"half", 0, 0.5, []int{0, 0, 1, 1, 2}},
+		{"negative is stopped", 0, -1, []int{0, 0, 0, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAnimation(testSheet(), []int{0, 1, 2, 3}, tt.speed)
+			a.SetPlaybackRate(tt.rate)
+			if got := playFrames(a, len(tt.want)-1); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("frames %v, want %v", got, tt.want)
+			}
+		})
+	}
+	a := NewAnimation(testSheet(), []int{0}, 0)
+	a.SetPlaybackRate(-2)
+	if a.PlaybackRate() != 0 {
+		t.Errorf("PlaybackRate() = %v after a negative rate, want 0", a.PlaybackRate())
+	}
+}
+
+func TestAnimationSeek(t *testing.T) {
+	tests := []struct {
+		name      string
+		repeating bool
+		seek      time.Duration
+		wantFrame int
+		wantTime  time.Duration
+	}{
+		{"within a loop", true, 150 * time.Millisecond, 1, 150 * time.Millisecond},
+		{"past the end repeating", true, 350 * time.Millisecond, 0, 50 * time.Millisecond},
+		{"exactly the end repeating", true, 300 * time.Millisecond, 0, 0},
+		{"past the end not repeating", false, 350 * time.Millisecond, 2, 300*time.Millisecond - 1},
+		{"negative", true, -time.Second, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAnimation(testSheet(), []int{0, 1, 2}, 0)
+			a.SetFrameDurations([]int{100, 100, 100})
+			a.Repeating = tt.repeating
+			a.Seek(tt.seek)
+			if a.CurrentFrame() != tt.wantFrame || a.Time() != tt.wantTime {
+				t.Errorf("frame %d at %v, want %d at %v", a.CurrentFrame(), a.Time(), tt.wantFrame, tt.wantTime)
+			}
+			if a.IsFinished() || a.LoopCompleted {
+				t.Error("seeking finished the animation")
+			}
+		})
+	}
+}
+
+func TestAnimationNotRepeating(t *testing.T) {
+	a := NewAnimation(testSheet(), []int{0, 1, 2}, 0)
+	a.Repeating = false
+	tests := []struct {
+		frame    int
+		loop     bool
+		finished bool
+	}{
+		{1, false, false},
+		{2, false, false},
+		{2, true, true},
+		{2, true, true},
+	}
+	for i, tt := range tests {
+		a.Update()
+		if a.CurrentFrame() != tt.frame || a.LoopCompleted != tt.loop || a.IsFinished() != tt.finished {
+			t.Fatalf("update %d: frame %d, LoopCompleted %v, IsFinished %v, want %d, %v, %v",
+				i+1, a.CurrentFrame(), a.LoopCompleted, a.IsFinished(), tt.frame, tt.loop, tt.finished)
+		}
+	}
+	if !a.IsPaused() {
+		t.Error("finished animation is not paused")
+	}
+	a.Play()
+	if a.CurrentFrame() != 0 || a.IsFinished() || a.LoopCompleted {
+		t.Errorf("Play after finishing: frame %d, IsFinished %v, LoopCompleted %v", a.CurrentFrame(), a.IsFinished(), a.LoopCompleted)
+	}
+}
+
+func TestAnimationRepeatingLoopCompleted(t *testing.T) {
+	a := NewAnimation(testSheet(), []int{0, 1}, 0)
+	want := []bool{false, true, false, true}
+	for i, w := range want {
+		a.Update()
+		if a.LoopCompleted != w || a.IsFinished() {
+			t.Fatalf("update %d: LoopCompleted %v, IsFinished %v, want %v, false", i+1, a.LoopCompleted, a.IsFinished(), w)
+		}
+	}
+}
+
+func TestAnimatorEventsOfSkippedFrames(t *testing.T) {
+	a := NewAnimation(testSheet(), []int{0, 1, 2, 3}, 1)
+	a.SetPlaybackRate(3)
+	an := NewAnimator()
+	an.AddClip("run", a)
+	an.AddEventRange("run", "frame", 0, 3)
+	got := []int{}
+	an.OnEvent("frame", func(e AnimationEvent, frame int) {
+		got = append(got, frame)
+	})
+	for i := 0; i < 4; i++ {
+		an.Update()
+	}
+	//Each tick covers one and a half frames, the events carry on across the end of the loop
+	want := []int{1, 2, 3, 0, 1, 2}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events fired on frames %v, want %v", got, want)
+	}
+}
+
+func TestAnimatorLeavingAtEndSkipsWrappedFrame(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(an *Animator)
+		want  int
+	}{
+		{"play once", func(an *Animator) { an.PlayOnce("attack", "idle") }, 1},
+		{"queue", func(an *Animator) { an.Play("attack"); an.Queue("idle") }, 1},
+		{"transition at end", func(an *Animator) {
+			an.AddTransition("attack", "idle", nil).AtEnd = true
+			an.Play("attack")
+		}, 1},
+		{"repeating", func(an *Animator) { an.Play("attack") }, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			an := NewAnimator()
+			an.AddClip("idle", NewAnimation(testSheet(), []int{0}, 0))
+			an.AddClip("attack", NewAnimation(testSheet(), []int{0, 1, 2, 3}, 0))
+			an.AddEvent("attack", "hit", 0)
+			hits := 0
+			an.OnEvent("hit", func(AnimationEvent, int) { hits++ })
+			tt.setup(an)
+			for i := 0; i < 8; i++ {
+				an.Update()
+			}
+			if hits != tt.want {
+				t.Errorf("hit fired %d times, want %d", hits, tt.want)
+			}
+		})
+	}
+}
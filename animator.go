@@ -0,0 +1,405 @@
+package tentsuyu
+
+import "time"
+
+//AnyClip is the From of an AnimatorTransition that can be taken from every clip
+const AnyClip = ""
+
+//Animator is a state machine owning the named Animations, or clips, of an entity.
+//Transitions switch clips when their parameters or triggers say so, clips can be played once before returning
+//to another or queued, and named events fire callbacks when a clip reaches their frames.
+//Clips can crossfade into each other, see Crossfade for drawing both frames while they do.
+type Animator struct {
+	Clips         map[string]*Animation
+	Transitions   []*AnimatorTransition
+	OnClipChange  func(from, to string)
+	params        map[string]float64
+	triggers      map[string]bool
+	events        map[string][]AnimationEvent
+	handlers      map[string][]func(e AnimationEvent, frame int)
+	current       string
+	queue         []string
+	once          bool
+	returnTo      string
+	lastFrame     int
+	tickRemainder float64
+	fadeClip      string
+	fadeFrom      *Animation
+	fadeElapsed   time.Duration
+	fadeDuration  time.Duration
+}
+
+//AnimatorTransition switches the Animator from the clip From, or any clip with AnyClip, to the clip To.
+//It is taken once Condition returns true and Trigger, if set, has been fired. AtEnd waits for the end of the loop of From.
+//A Crossfade above 0 fades From out and To in over that long instead of switching at once.
+type AnimatorTransition struct {
+	From      string
+	To        string
+	Condition func(an *Animator) bool
+	Trigger   string
+	AtEnd     bool
+	Crossfade time.Duration
+}
+
+//AnimationEvent is a named range of frames of a clip, such as "footstep" on frame 3 or "hitbox-active" on frames 5 to 7.
+//Frames are positions in the Frames of the clip's Animation as returned by CurrentFrame.
+type AnimationEvent struct {
+	Name     string
+	Clip     string
+	From, To int
+}
+
+//NewAnimator returns an Animator without clips
+func NewAnimator() *Animator {
+	return &Animator{
+		Clips:    map[string]*Animation{},
+		params:   map[string]float64{},
+		triggers: map[string]bool{},
+		events:   map[string][]AnimationEvent{},
+		handlers: map[string][]func(e AnimationEvent, frame int){},
+	}
+}
+
+//AddClip adds the Animation under the name. The first clip added is played.
+func (an *Animator) AddClip(name string, a *Animation) {
+	an.Clips[name] = a
+	if an.current == "" {
+		an.start(name)
+	}
+}
+
+//AddTagClips adds a clip for every frame tag of an Aseprite sprite sheet, named by the tag
+func (an *Animator) AddTagClips(sheet *SpriteSheet) error {
+	for _, tag := range sheet.Meta.FrameTags {
+		a, err := sheet.TagAnimation(tag.Name)
+		if err != nil {
+			return err
+		}
+		an.AddClip(tag.Name, a)
+	}
+	return nil
+}
+
+//AddTransition adds a transition from one clip to another, taken when the condition returns true.
+//A nil condition is always true. The transition is returned so a Trigger, AtEnd or Crossfade can be set.
+func (an *Animator) AddTransition(from, to string, condition func(an *Animator) bool) *AnimatorTransition {
+	t := &AnimatorTransition{From: from, To: to, Condition: condition}
+	an.Transitions = append(an.Transitions, t)
+	return t
+}
+
+//AddTriggerTransition adds a transition from one clip to another taken when the trigger is fired
+func (an *Animator) AddTriggerTransition(from, to, trigger string) *AnimatorTransition {
+	t := an.AddTransition(from, to, nil)
+	t.Trigger = trigger
+	return t
+}
+
+//AddEvent fires the named event when the clip reaches the frame
+func (an *Animator) AddEvent(clip, name string, frame int) {
+	an.AddEventRange(clip, name, frame, frame)
+}
+
+//AddEventRange fires the named event on every frame of the clip from one frame to another, inclusive
+func (an *Animator) AddEventRange(clip, name string, from, to int) {
+	an.events[clip] = append(an.events[clip], AnimationEvent{Name: name, Clip: clip, From: from, To: to})
+}
+
+//OnEvent calls fn with the event and the frame reached whenever the named event fires
+func (an *Animator) OnEvent(name string, fn func(e AnimationEvent, frame int)) {
+	an.handlers[name] = append(an.handlers[name], fn)
+}
+
+//EventActive returns true if the current frame of the current clip is within the named event
+func (an *Animator) EventActive(name string) bool {
+	a := an.Current()
+	if a == nil {
+		return false
+	}
+	frame := a.CurrentFrame()
+	for _, e := range an.events[an.current] {
+		if e.Name == name && frame >= e.From && frame <= e.To {
+			return true
+		}
+	}
+	return false
+}
+
+//SetFloat sets a parameter read by transition conditions, such as "speed"
+func (an *Animator) SetFloat(name string, v float64) {
+	an.params[name] = v
+}
+
+//Float returns the parameter, 0 if it was never set
+func (an *Animator) Float(name string) float64 {
+	return an.params[name]
+}
+
+//SetBool sets a parameter to 1 for true or 0 for false
+func (an *Animator) SetBool(name string, b bool) {
+	if b {
+		an.params[name] = 1
+	} else {
+		an.params[name] = 0
+	}
+}
+
+//Bool returns true if the parameter is not 0
+func (an *Animator) Bool(name string) bool {
+	return an.params[name] != 0
+}
+
+//SetTrigger fires the trigger. It stays set until a transition using it is taken or ResetTrigger is called.
+func (an *Animator) SetTrigger(name string) {
+	an.triggers[name] = true
+}
+
+//ResetTrigger clears the trigger
+func (an *Animator) ResetTrigger(name string) {
+	delete(an.triggers, name)
+}
+
+//ParamAbove returns a condition that is true while the parameter is greater than v
+func ParamAbove(name string, v float64) func(an *Animator) bool {
+	return func(an *Animator) bool { return an.Float(name) > v }
+}
+
+//ParamBelow returns a condition that is true while the parameter is less than v
+func ParamBelow(name string, v float64) func(an *Animator) bool {
+	return func(an *Animator) bool { return an.Float(name) < v }
+}
+
+//ParamTrue returns a condition that is true while the bool parameter is set
+func ParamTrue(name string) func(an *Animator) bool {
+	return func(an *Animator) bool { return an.Bool(name) }
+}
+
+//ParamFalse returns a condition that is true while the bool parameter is not set
+func ParamFalse(name string) func(an *Animator) bool {
+	return func(an *Animator) bool { return !an.Bool(name) }
+}
+
+//Current returns the Animation of the current clip
+func (an *Animator) Current() *Animation {
+	return an.Clips[an.current]
+}
+
+//CurrentClip returns the name of the current clip
+func (an *Animator) CurrentClip() string {
+	return an.current
+}
+
+//ReturnImageParts of the current clip
+func (an *Animator) ReturnImageParts() *BasicImageParts {
+	if a := an.Current(); a != nil {
+		return a.ImageParts
+	}
+	return nil
+}
+
+//Play switches to the clip from its first frame, dropping any queued clips
+func (an *Animator) Play(name string) {
+	an.queue = nil
+	an.once = false
+	an.start(name)
+}
+
+//PlayCrossfade switches to the clip from its first frame like Play, fading out the current clip over d.
+//The outgoing clip keeps playing until the fade ends.
+func (an *Animator) PlayCrossfade(name string, d time.Duration) {
+	an.queue = nil
+	an.once = false
+	an.crossfade(name, d)
+}
+
+//Crossfade returns what to draw while the Animator fades between clips: the frame of the outgoing clip,
+//the frame of the current clip and the weight of the current clip from 0 to 1. Draw from with an alpha of
+//1-weight and to over it with an alpha of weight. When not fading from is nil and weight is 1.
+func (an *Animator) Crossfade() (from, to *BasicImageParts, weight float64) {
+	to = an.ReturnImageParts()
+	if an.fadeFrom == nil {
+		return nil, to, 1
+	}
+	return an.fadeFrom.ImageParts, to, float64(an.fadeElapsed) / float64(an.fadeDuration)
+}
+
+//Fading returns true while the Animator crossfades from another clip
+func (an *Animator) Fading() bool {
+	return an.fadeFrom != nil
+}
+
+//FadingClip returns the name of the clip being faded out, empty when not fading
+func (an *Animator) FadingClip() string {
+	return an.fadeClip
+}
+
+//PlayOnce plays the clip for one loop and then returns to the clip returnTo,
+//or the clip that was playing when returnTo is empty
+func (an *Animator) PlayOnce(name, returnTo string) {
+	if returnTo == "" {
+		returnTo = an.current
+	}
+	an.queue = nil
+	an.start(name)
+	an.once = true
+	an.returnTo = returnTo
+}
+
+//Queue plays the clips one loop each after the current loop of the current clip ends. The last clip keeps playing.
+func (an *Animator) Queue(names ...string) {
+	an.queue = append(an.queue, names...)
+}
+
+//Queued returns the clips waiting to be played
+func (an *Animator) Queued() []string {
+	return an.queue
+}
+
+//crossfade starts the clip and fades out the current one over d, or switches at once when d is not above 0
+func (an *Animator) crossfade(name string, d time.Duration) {
+	prev, from := an.current, an.Current()
+	an.start(name)
+	if d <= 0 || from == nil || an.current == prev || an.Current() == from {
+		return
+	}
+	an.fadeClip, an.fadeFrom = prev, from
+	an.fadeElapsed, an.fadeDuration = 0, d
+}
+
+//stopFade ends any crossfade, leaving only the current clip
+func (an *Animator) stopFade() {
+	an.fadeClip, an.fadeFrom = "", nil
+	an.fadeElapsed, an.fadeDuration = 0, 0
+}
+
+//start switches to the clip from its starting point and fires the events of that frame.
+//Any crossfade is cut short.
+func (an *Animator) start(name string) {
+	a, ok := an.Clips[name]
+	if !ok {
+		return
+	}
+	an.stopFade()
+	prev := an.current
+	an.current = name
+	an.once = false
+	a.Reset()
+	an.lastFrame = a.CurrentFrame()
+	if an.OnClipChange != nil && prev != name {
+		an.OnClipChange(prev, name)
+	}
+	an.fire(an.lastFrame)
+}
+
+//Update takes any transition whose conditions are met and advances the current clip by a tick,
+//firing the events of every frame reached, including those passed within the tick. A clip being faded out is advanced too, without events.
+func (an *Animator) Update() {
+	an.transition(false)
+	if an.fadeFrom != nil {
+		an.fadeElapsed += animationTick
+		if an.fadeElapsed >= an.fadeDuration {
+			an.stopFade()
+		} else {
+			an.fadeFrom.Update()
+		}
+	}
+	a := an.Current()
+	if a == nil {
+		return
+	}
+	loops, advanced := a.loops, a.advanced
+	step := a.step
+	if step >= a.sequenceLen() {
+		step = 0
+	}
+	a.Update()
+	ended := a.loops != loops
+	if passed := a.advanced - advanced; passed > 0 {
+		//A fast clip can pass several frames in a tick, possibly across the end of a loop
+		if last := a.sequenceLen() - 1 - step; ended && passed > last && an.leavingAtEnd() {
+			//The clip is left at the end of its loop, the frames it wrapped around to are never shown
+			passed = last
+		}
+		for i := 1; i <= passed; i++ {
+			an.fire(a.sequenceAt((step + i) % a.sequenceLen()))
+		}
+		an.lastFrame = a.CurrentFrame()
+	} else if frame := a.CurrentFrame(); frame != an.lastFrame {
+		an.lastFrame = frame
+		an.fire(frame)
+	}
+	if !ended {
+		return
+	}
+	switch {
+	case len(an.queue) > 0:
+		next := an.queue[0]
+		an.queue = an.queue[1:]
+		an.start(next)
+	case an.once:
+		an.once = false
+		an.start(an.returnTo)
+	default:
+		an.transition(true)
+	}
+}
+
+//UpdateDelta advances the Animator by dt seconds, see Animation.UpdateDelta
+func (an *Animator) UpdateDelta(dt float64) {
+	an.tickRemainder += dt * referenceTPS
+	for an.tickRemainder >= 1 {
+		an.tickRemainder--
+		an.Update()
+	}
+}
+
+//transition takes the first transition from the current clip that can be taken.
+//Transitions waiting for the end of a loop are only considered when atEnd is true.
+func (an *Animator) transition(atEnd bool) {
+	t := an.takeable(atEnd)
+	if t == nil {
+		return
+	}
+	if t.Trigger != "" {
+		delete(an.triggers, t.Trigger)
+	}
+	an.queue = nil
+	an.crossfade(t.To, t.Crossfade)
+}
+
+//takeable returns the first transition from the current clip that can be taken, nil if there is none
+func (an *Animator) takeable(atEnd bool) *AnimatorTransition {
+	for _, t := range an.Transitions {
+		if t.AtEnd != atEnd || t.To == an.current {
+			continue
+		}
+		if t.From != AnyClip && t.From != an.current {
+			continue
+		}
+		if t.Trigger != "" && !an.triggers[t.Trigger] {
+			continue
+		}
+		if t.Condition != nil && !t.Condition(an) {
+			continue
+		}
+		return t
+	}
+	return nil
+}
+
+//leavingAtEnd reports whether the current clip is switched away from when its loop ends
+func (an *Animator) leavingAtEnd() bool {
+	return len(an.queue) > 0 || an.once || an.takeable(true) != nil
+}
+
+//fire calls the handlers of the events of the current clip covering the frame
+func (an *Animator) fire(frame int) {
+	for _, e := range an.events[an.current] {
+		if frame < e.From || frame > e.To {
+			continue
+		}
+		for _, fn := range an.handlers[e.Name] {
+			fn(e, frame)
+		}
+	}
+}
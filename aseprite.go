@@ -0,0 +1,219 @@
+package tentsuyu
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+//Directions a FrameTag plays its frames in, as exported by Aseprite
+const (
+	FrameTagForward         = "forward"
+	FrameTagReverse         = "reverse"
+	FrameTagPingPong        = "pingpong"
+	FrameTagPingPongReverse = "pingpong_reverse"
+)
+
+//defaultFrameDuration is the duration Aseprite gives a frame, used when a sprite sheet has no durations
+const defaultFrameDuration = 100
+
+//FrameTag is a named range of frames of an Aseprite sprite sheet, such as "walk" or "attack"
+type FrameTag struct {
+	Name      string `json:"name"`
+	From      int    `json:"from"`
+	To        int    `json:"to"`
+	Direction string `json:"direction"`
+	Color     string `json:"color"`
+	Data      string `json:"data"`
+}
+
+//Frames returns the frames of the tag in the order they are played by an animation looping over them.
+//A ping-pong tag plays back to the frame after its first so the loop does not show the ends twice.
+func (t *FrameTag) Frames() []int {
+	forward := []int{}
+	for f := t.From; f <= t.To; f++ {
+		forward = append(forward, f)
+	}
+	backward := make([]int, len(forward))
+	for i, f := range forward {
+		backward[len(forward)-1-i] = f
+	}
+	switch t.Direction {
+	case FrameTagReverse:
+		return backward
+	case FrameTagPingPong:
+		return append(forward, innerFrames(backward)...)
+	case FrameTagPingPongReverse:
+		return append(backward, innerFrames(forward)...)
+	}
+	return forward
+}
+
+//innerFrames returns the frames without the first and last
+func innerFrames(frames []int) []int {
+	if len(frames) < 3 {
+		return nil
+	}
+	return frames[1 : len(frames)-1]
+}
+
+//Slice is a named region of an Aseprite sprite, such as a hitbox or the borders of a 9-slice panel.
+//Each key sets the region from its frame onwards.
+type Slice struct {
+	Name  string     `json:"name"`
+	Color string     `json:"color"`
+	Data  string     `json:"data"`
+	Keys  []SliceKey `json:"keys"`
+}
+
+//SliceKey is the region of a Slice from Frame onwards. Center is only set for 9-slices and Pivot for slices with a pivot,
+//both are relative to Bounds.
+type SliceKey struct {
+	Frame  int         `json:"frame"`
+	Bounds SliceRect   `json:"bounds"`
+	Center *SliceRect  `json:"center"`
+	Pivot  *SlicePoint `json:"pivot"`
+}
+
+//SliceRect is a rectangle of a Slice in pixels
+type SliceRect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+//SlicePoint is a point of a Slice in pixels
+type SlicePoint struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+//Key returns the key of the slice in effect on the frame, nil if the slice starts on a later frame
+func (sl *Slice) Key(frame int) *SliceKey {
+	var key *SliceKey
+	for i := range sl.Keys {
+		if sl.Keys[i].Frame <= frame && (key == nil || sl.Keys[i].Frame >= key.Frame) {
+			key = &sl.Keys[i]
+		}
+	}
+	return key
+}
+
+//IsNineSlice returns true if the key has the center of a 9-slice
+func (k *SliceKey) IsNineSlice() bool {
+	return k.Center != nil
+}
+
+//UnmarshalJSON reads the frames of a sprite sheet as either an array, as written by TexturePacker and the Aseprite array format,
+//or a hash keyed by filename, as written by the Aseprite hash format. The order of a hash is kept so frame numbers match the tags.
+func (s *SpriteSheet) UnmarshalJSON(b []byte) error {
+	var aux struct {
+		Frames json.RawMessage `json:"frames"`
+		Meta   SpriteSheetMeta `json:"meta"`
+	}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	s.Meta = aux.Meta
+	s.Frames = nil
+	raw := bytes.TrimSpace(aux.Frames)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil
+	}
+	switch raw[0] {
+	case '[':
+		return json.Unmarshal(raw, &s.Frames)
+	case '{':
+	default:
+		return errors.New("sprite sheet frames must be an array or an object")
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		f := Frame{}
+		if err := dec.Decode(&f); err != nil {
+			return err
+		}
+		if f.Filename == "" {
+			f.Filename, _ = key.(string)
+		}
+		s.Frames = append(s.Frames, f)
+	}
+	return nil
+}
+
+//Tag returns the frame tag with the name, nil if there is none
+func (s *SpriteSheet) Tag(name string) *FrameTag {
+	for i := range s.Meta.FrameTags {
+		if s.Meta.FrameTags[i].Name == name {
+			return &s.Meta.FrameTags[i]
+		}
+	}
+	return nil
+}
+
+//Slice returns the slice with the name, nil if there is none
+func (s *SpriteSheet) Slice(name string) *Slice {
+	for i := range s.Meta.Slices {
+		if s.Meta.Slices[i].Name == name {
+			return &s.Meta.Slices[i]
+		}
+	}
+	return nil
+}
+
+//TagAnimation returns a new Animation playing the frames of the named tag in its direction,
+//showing each frame for its duration. CurrentFrame counts from the first frame of the tag.
+func (s *SpriteSheet) TagAnimation(name string) (*Animation, error) {
+	tag := s.Tag(name)
+	if tag == nil {
+		return nil, fmt.Errorf("tentsuyu: sprite sheet has no frame tag %q", name)
+	}
+	if tag.From < 0 || tag.To >= len(s.Frames) || tag.From > tag.To {
+		return nil, fmt.Errorf("tentsuyu: frame tag %q has frames %d to %d but the sprite sheet has %d", name, tag.From, tag.To, len(s.Frames))
+	}
+	frames := []int{}
+	durations := []int{}
+	for f := tag.From; f <= tag.To; f++ {
+		d := s.Frames[f].Duration
+		if d <= 0 {
+			d = defaultFrameDuration
+		}
+		frames = append(frames, f)
+		durations = append(durations, d)
+	}
+	a := NewAnimation(s, frames, 0)
+	a.SetFrameDurations(durations)
+	switch tag.Direction {
+	case FrameTagReverse:
+		a.SetReverse()
+	case FrameTagPingPong:
+		a.SetPingPong(true)
+	case FrameTagPingPongReverse:
+		a.SetPingPong(true)
+		a.SetReverse()
+	}
+	a.Reset()
+	return a, nil
+}
+
+//TagAnimations returns a new Animation for every frame tag of the sprite sheet by the name of the tag
+func (s *SpriteSheet) TagAnimations() (map[string]*Animation, error) {
+	anims := make(map[string]*Animation, len(s.Meta.FrameTags))
+	for _, tag := range s.Meta.FrameTags {
+		a, err := s.TagAnimation(tag.Name)
+		if err != nil {
+			return nil, err
+		}
+		anims[tag.Name] = a
+	}
+	return anims, nil
+}
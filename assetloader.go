@@ -0,0 +1,496 @@
+package tentsuyu
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"io/fs"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang/freetype/truetype"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/audio"
+)
+
+//Asset kinds of a Manifest
+const (
+	AssetImage       = "image"
+	AssetAtlas       = "atlas"
+	AssetSpriteSheet = "spritesheet"
+	AssetSoundEffect = "sound"
+	AssetSong        = "song"
+	AssetFont        = "font"
+	AssetMap         = "map"
+)
+
+//Manifest lists the assets a game, or one part of it, needs loaded
+type Manifest struct {
+	Images       []*ManifestAsset `json:"images,omitempty" yaml:"images,omitempty"`
+	Atlases      []*ManifestAsset `json:"atlases,omitempty" yaml:"atlases,omitempty"`
+	SpriteSheets []*ManifestAsset `json:"spritesheets,omitempty" yaml:"spritesheets,omitempty"`
+	Sounds       []*ManifestAsset `json:"sounds,omitempty" yaml:"sounds,omitempty"`
+	Songs        []*ManifestAsset `json:"songs,omitempty" yaml:"songs,omitempty"`
+	Fonts        []*ManifestAsset `json:"fonts,omitempty" yaml:"fonts,omitempty"`
+	Maps         []*ManifestAsset `json:"maps,omitempty" yaml:"maps,omitempty"`
+}
+
+//ManifestAsset is a single file of a Manifest. The Name is what the asset is stored under,
+//the path is used when it is empty. Volume is only used by sounds and defaults to 1.
+type ManifestAsset struct {
+	Name   string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Path   string   `json:"path" yaml:"path"`
+	Volume *float64 `json:"volume,omitempty" yaml:"volume,omitempty"`
+}
+
+//AssetName returns the name the asset is stored under
+func (a *ManifestAsset) AssetName() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	return a.Path
+}
+
+//manifestFormats holds the decoders of manifest files by extension
+var manifestFormats = map[string]func([]byte, interface{}) error{
+	".json": json.Unmarshal,
+}
+
+//RegisterManifestFormat lets ReadManifestFS read manifests with the extension, such as ".yaml",
+//using the unmarshal function of a decoding package, such as yaml.Unmarshal
+func RegisterManifestFormat(ext string, unmarshal func([]byte, interface{}) error) {
+	manifestFormats[strings.ToLower(ext)] = unmarshal
+}
+
+//ReadManifest reads a JSON Manifest
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	m := &Manifest{}
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, &MalformedJSONError{Err: err}
+	}
+	return m, nil
+}
+
+//ReadManifestFS reads the Manifest from the file system in any format registered by its extension.
+//Only .json is read out of the box, YAML and other formats need a decoder registered first,
+//e.g. RegisterManifestFormat(".yaml", yaml.Unmarshal), otherwise an UnknownFormatError is returned.
+func ReadManifestFS(fsys fs.FS, name string) (*Manifest, error) {
+	ext := strings.ToLower(path.Ext(name))
+	unmarshal, ok := manifestFormats[ext]
+	if !ok {
+		return nil, &UnknownFormatError{Kind: "manifest", Path: name, Expected: manifestExtensions()}
+	}
+	raw, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	if err := unmarshal(raw, m); err != nil {
+		if ext == ".json" {
+			return nil, &MalformedJSONError{Path: name, Err: err}
+		}
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return m, nil
+}
+
+//manifestExtensions returns the sorted extensions of the registered manifest formats
+func manifestExtensions() []string {
+	exts := make([]string, 0, len(manifestFormats))
+	for ext := range manifestFormats {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+//AssetError is the failure to load one asset of a Manifest
+type AssetError struct {
+	Kind string
+	Name string
+	Path string
+	Err  error
+}
+
+func (e *AssetError) Error() string {
+	return fmt.Sprintf("loading %s %q from %s: %v", e.Kind, e.Name, e.Path, e.Err)
+}
+
+//Unwrap returns the underlying error
+func (e *AssetError) Unwrap() error {
+	return e.Err
+}
+
+//assetJob loads one asset away from the game loop and returns the function that stores it in the Game,
+//which can still fail on the game loop
+type assetJob struct {
+	kind  string
+	asset *ManifestAsset
+	load  func() (func(g *Game) error, error)
+}
+
+//assetResult is a finished assetJob
+type assetResult struct {
+	job   *assetJob
+	store func(g *Game) error
+	err   error
+}
+
+//AssetLoader reads and decodes the assets of a Manifest from a file system on several goroutines.
+//Finished assets are added to the Game by Update, on the game loop, so nothing is shared with the loading goroutines.
+//Images and atlases go to the ImageManager, sounds and songs to the AudioPlayer, fonts to the UIController
+//and sprite sheets and maps to the SpriteSheets and TileMaps of the Game.
+type AssetLoader struct {
+	FS       fs.FS
+	Manifest *Manifest
+	//Workers is the number of assets loaded at the same time, the number of CPUs if not set
+	Workers int
+	//OnAssetLoaded is called by Update for every asset that has been added to the Game
+	OnAssetLoaded func(kind, name string)
+	//Bundle, when set, holds a reference to every asset loaded so they can be unloaded together
+	Bundle  *AssetBundle
+	jobs    []*assetJob
+	results chan assetResult
+	done    int
+	errs    []*AssetError
+	started bool
+	once    sync.Once
+}
+
+//NewAssetLoader creates a loader for the Manifest reading files from the file system
+func NewAssetLoader(fsys fs.FS, manifest *Manifest) *AssetLoader {
+	l := &AssetLoader{
+		FS:       fsys,
+		Manifest: manifest,
+	}
+	return l
+}
+
+//Start begins loading in the background, Update must then be called every tick to add the finished assets
+func (l *AssetLoader) Start() {
+	l.once.Do(func() {
+		l.jobs = l.createJobs()
+		l.results = make(chan assetResult, len(l.jobs))
+		l.started = true
+		workers := l.Workers
+		if workers <= 0 {
+			workers = runtime.NumCPU()
+		}
+		queue := make(chan *assetJob)
+		for i := 0; i < workers; i++ {
+			go func() {
+				for job := range queue {
+					store, err := job.load()
+					l.results <- assetResult{job: job, store: store, err: err}
+				}
+			}()
+		}
+		go func() {
+			for _, job := range l.jobs {
+				queue <- job
+			}
+			close(queue)
+		}()
+	})
+}
+
+//Update adds the assets that have finished loading to the Game and returns true once every asset is done
+func (l *AssetLoader) Update(g *Game) bool {
+	l.Start()
+	for {
+		select {
+		case r := <-l.results:
+			l.done++
+			err := r.err
+			if err == nil {
+				//Storing can still fail, the asset then counts as failed and is not passed to OnAssetLoaded
+				err = r.store(g)
+			}
+			if err != nil {
+				l.errs = append(l.errs, &AssetError{Kind: r.job.kind, Name: r.job.asset.AssetName(), Path: r.job.asset.Path, Err: err})
+				continue
+			}
+			if l.OnAssetLoaded != nil {
+				l.OnAssetLoaded(r.job.kind, r.job.asset.AssetName())
+			}
+		default:
+			return l.Done()
+		}
+	}
+}
+
+//Done returns true once every asset has been loaded or has failed
+func (l *AssetLoader) Done() bool {
+	return l.started && l.done == len(l.jobs)
+}
+
+//Total returns the number of assets in the Manifest
+func (l *AssetLoader) Total() int {
+	if !l.started {
+		return len(l.createJobs())
+	}
+	return len(l.jobs)
+}
+
+//Loaded returns the number of assets that have finished, including those that failed
+func (l *AssetLoader) Loaded() int {
+	return l.done
+}
+
+//Progress returns how much of the Manifest has finished loading from 0 to 1
+func (l *AssetLoader) Progress() float64 {
+	total := l.Total()
+	if l.Done() || total == 0 {
+		return 1
+	}
+	return float64(l.done) / float64(total)
+}
+
+//Percent returns the Progress as a whole percentage
+func (l *AssetLoader) Percent() int {
+	return int(l.Progress() * 100)
+}
+
+//Errors returns the assets that failed to load so far
+func (l *AssetLoader) Errors() []*AssetError {
+	return l.errs
+}
+
+//Err returns the first asset that failed to load, nil if every asset loaded
+func (l *AssetLoader) Err() error {
+	if len(l.errs) == 0 {
+		return nil
+	}
+	if len(l.errs) == 1 {
+		return l.errs[0]
+	}
+	return fmt.Errorf("%v (and %d more)", l.errs[0], len(l.errs)-1)
+}
+
+//createJobs creates a job for every asset of the Manifest
+func (l *AssetLoader) createJobs() []*assetJob {
+	m := l.Manifest
+	if m == nil {
+		return nil
+	}
+	jobs := []*assetJob{}
+	add := func(kind string, assets []*ManifestAsset, load func(a *ManifestAsset) (func(g *Game) error, error)) {
+		for _, a := range assets {
+			a := a
+			jobs = append(jobs, &assetJob{kind: kind, asset: a, load: func() (func(g *Game) error, error) { return load(a) }})
+		}
+	}
+	add(AssetImage, m.Images, l.loadImage)
+	add(AssetAtlas, m.Atlases, l.loadAtlas)
+	add(AssetSpriteSheet, m.SpriteSheets, l.loadSpriteSheet)
+	add(AssetSoundEffect, m.Sounds, l.loadSound)
+	add(AssetSong, m.Songs, l.loadSong)
+	add(AssetFont, m.Fonts, l.loadFont)
+	add(AssetMap, m.Maps, l.loadMap)
+	return jobs
+}
+
+//track adds the stored asset to the Bundle of the loader
+func (l *AssetLoader) track(kind, name string) {
+	if l.Bundle != nil {
+		l.Bundle.Add(kind, name)
+	}
+}
+
+//decodeImage reads and decodes an image of the file system
+func decodeImage(fsys fs.FS, name string) (image.Image, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, imageError(name, err)
+}
+
+func (l *AssetLoader) loadImage(a *ManifestAsset) (func(g *Game) error, error) {
+	img, err := decodeImage(l.FS, a.Path)
+	if err != nil {
+		return nil, err
+	}
+	return func(g *Game) error {
+		//Decoding is the slow part, the upload to the GPU happens on the game loop
+		eimg, err := ebiten.NewImageFromImage(img, ebiten.FilterNearest)
+		if err != nil {
+			return err
+		}
+		g.ImageManager.AddImage(a.AssetName(), eimg)
+		l.track(AssetImage, a.AssetName())
+		return nil
+	}, nil
+}
+
+func (l *AssetLoader) loadAtlas(a *ManifestAsset) (func(g *Game) error, error) {
+	type page struct {
+		sheet *SpriteSheet
+		img   image.Image
+	}
+	pages := []page{}
+	queue := []string{a.Path}
+	seen := map[string]bool{}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		raw, err := fs.ReadFile(l.FS, name)
+		if err != nil {
+			return nil, err
+		}
+		sheet := &SpriteSheet{}
+		if err := unmarshalJSON(name, raw, sheet); err != nil {
+			return nil, err
+		}
+		if sheet.Meta.Image == "" {
+			return nil, fmt.Errorf("%s: the sprite sheet does not name its image", name)
+		}
+		dir := path.Dir(name)
+		img, err := decodeImage(l.FS, path.Join(dir, sheet.Meta.Image))
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, page{sheet: sheet, img: img})
+		for _, related := range sheet.Meta.RelatedMultiPacks {
+			queue = append(queue, path.Join(dir, related))
+		}
+	}
+	return func(g *Game) error {
+		//Every page is uploaded before any is added so a failed upload leaves none of the atlas behind
+		eimgs := make([]*ebiten.Image, len(pages))
+		for i, p := range pages {
+			eimg, err := ebiten.NewImageFromImage(p.img, ebiten.FilterNearest)
+			if err != nil {
+				for _, done := range eimgs[:i] {
+					done.Dispose()
+				}
+				return err
+			}
+			eimgs[i] = eimg
+		}
+		for i, p := range pages {
+			g.ImageManager.AddAtlas(p.sheet, eimgs[i])
+			for _, f := range p.sheet.Frames {
+				l.track(AssetImage, f.Filename)
+			}
+			l.track(AssetImage, p.sheet.Meta.Image)
+		}
+		return nil
+	}, nil
+}
+
+func (l *AssetLoader) loadSpriteSheet(a *ManifestAsset) (func(g *Game) error, error) {
+	raw, err := fs.ReadFile(l.FS, a.Path)
+	if err != nil {
+		return nil, err
+	}
+	sheet := &SpriteSheet{}
+	if err := unmarshalJSON(a.Path, raw, sheet); err != nil {
+		return nil, err
+	}
+	return func(g *Game) error {
+		g.SpriteSheets[a.AssetName()] = sheet
+		l.track(AssetSpriteSheet, a.AssetName())
+		return nil
+	}, nil
+}
+
+func (l *AssetLoader) loadSound(a *ManifestAsset) (func(g *Game) error, error) {
+	raw, err := fs.ReadFile(l.FS, a.Path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := decodeSoundEffect(raw)
+	if err != nil {
+		return nil, audioError(a.Path, err)
+	}
+	volume := 1.0
+	if a.Volume != nil {
+		volume = *a.Volume
+	}
+	return func(g *Game) error {
+		g.AudioPlayer.seBytes[a.AssetName()] = b
+		g.AudioPlayer.seVolume[a.AssetName()] = volume
+		l.track(AssetSoundEffect, a.AssetName())
+		return nil
+	}, nil
+}
+
+func (l *AssetLoader) loadSong(a *ManifestAsset) (func(g *Game) error, error) {
+	raw, err := fs.ReadFile(l.FS, a.Path)
+	if err != nil {
+		return nil, err
+	}
+	var player *audio.Player
+	if player, err = newSongPlayer(raw); err != nil {
+		return nil, audioError(a.Path, err)
+	}
+	return func(g *Game) error {
+		g.AudioPlayer.songs[a.AssetName()] = player
+		l.track(AssetSong, a.AssetName())
+		return nil
+	}, nil
+}
+
+func (l *AssetLoader) loadFont(a *ManifestAsset) (func(g *Game) error, error) {
+	raw, err := fs.ReadFile(l.FS, a.Path)
+	if err != nil {
+		return nil, err
+	}
+	font, err := truetype.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	return func(g *Game) error {
+		g.UIController.AddFont(a.AssetName(), font)
+		l.track(AssetFont, a.AssetName())
+		return nil
+	}, nil
+}
+
+func (l *AssetLoader) loadMap(a *ManifestAsset) (func(g *Game) error, error) {
+	tm, err := CreateTileMapFS(l.FS, a.Path)
+	if err != nil {
+		return nil, err
+	}
+	return func(g *Game) error {
+		g.TileMaps[a.AssetName()] = tm
+		l.track(AssetMap, a.AssetName())
+		return nil
+	}, nil
+}
+
+//LoadAssets reads the Manifest from the file system and pushes a LoadingState that loads it.
+//The manifest is read by ReadManifestFS, so YAML manifests need their decoder registered first.
+//The LoadingState is replaced by next once every asset has loaded.
+func (g *Game) LoadAssets(fsys fs.FS, manifest string, next GameState) (*LoadingState, error) {
+	m, err := ReadManifestFS(fsys, manifest)
+	if err != nil {
+		return nil, err
+	}
+	ls := NewLoadingState(NewAssetLoader(fsys, m), next)
+	if err := g.PushGameState(ls); err != nil {
+		return nil, err
+	}
+	return ls, nil
+}
+
+//LoadBundle is LoadAssets with every asset of the Manifest held by the named AssetBundle
+func (g *Game) LoadBundle(fsys fs.FS, manifest, bundle string, next GameState) (*LoadingState, error) {
+	ls, err := g.LoadAssets(fsys, manifest, next)
+	if err != nil {
+		return nil, err
+	}
+	ls.Loader.Bundle = g.Bundle(bundle)
+	return ls, nil
+}
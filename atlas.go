@@ -0,0 +1,106 @@
+package tentsuyu
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+//AddAtlas adds every frame of the SpriteSheet to the ImageManager as a sub-image of the page,
+//named by the filename of the frame. ReturnImage then hands out the frame as if it was its own image
+//while ebiten draws every frame from the single page texture.
+func (im *ImageManager) AddAtlas(sheet *SpriteSheet, page *ebiten.Image) {
+	for _, f := range sheet.Frames {
+		x, y := f.Frame["x"], f.Frame["y"]
+		r := image.Rect(x, y, x+f.Frame["w"], y+f.Frame["h"])
+		im.Images[f.Filename] = page.SubImage(r).(*ebiten.Image)
+	}
+	if sheet.Meta.Image != "" {
+		im.Images[sheet.Meta.Image] = page
+	}
+}
+
+//AddAtlasFromFile reads the sprite sheet JSON of an atlas, such as one written by the tentsuyuatlas command,
+//and loads the page image it names from the same directory. The other pages of the atlas are loaded too.
+func (im *ImageManager) AddAtlasFromFile(path string) error {
+	return im.addAtlasFromFile(path, map[string]bool{})
+}
+
+func (im *ImageManager) addAtlasFromFile(path string, loaded map[string]bool) error {
+	if loaded[path] {
+		return nil
+	}
+	loaded[path] = true
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	sheet := &SpriteSheet{}
+	if err := unmarshalJSON(path, raw, sheet); err != nil {
+		return err
+	}
+	if sheet.Meta.Image == "" {
+		return fmt.Errorf("%s: the sprite sheet does not name its image", path)
+	}
+	dir := filepath.Dir(path)
+	pagePath := filepath.Join(dir, sheet.Meta.Image)
+	page, _, err := ebitenutil.NewImageFromFile(pagePath, ebiten.FilterNearest)
+	if err != nil {
+		return imageError(pagePath, err)
+	}
+	im.AddAtlas(sheet, page)
+	for _, related := range sheet.Meta.RelatedMultiPacks {
+		if err := im.addAtlasFromFile(filepath.Join(dir, related), loaded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//AddAtlasFromBytes adds the atlas page from the sprite sheet JSON and page image in byte slices
+func (im *ImageManager) AddAtlasFromBytes(sheetJSON, pageImage []byte) error {
+	sheet, err := ReadSpriteSheetJSON(sheetJSON)
+	if err != nil {
+		return err
+	}
+	img, _, err := image.Decode(bytes.NewReader(pageImage))
+	if err != nil {
+		return imageError("", err)
+	}
+	page, err := ebiten.NewImageFromImage(img, ebiten.FilterNearest)
+	if err != nil {
+		return err
+	}
+	im.AddAtlas(sheet, page)
+	return nil
+}
+
+//offsetImageParts moves the source rectangles of ImageParts onto a sub-image
+type offsetImageParts struct {
+	ebiten.ImageParts
+	x, y int
+}
+
+//Src returns the source rectangle moved to the position of the sub-image
+func (p offsetImageParts) Src(i int) (x0, y0, x1, y1 int) {
+	x0, y0, x1, y1 = p.ImageParts.Src(i)
+	return x0 + p.x, y0 + p.y, x1 + p.x, y1 + p.y
+}
+
+//ImagePartsFor returns the ImageParts to draw from the image. Sub-images, such as the frames of an atlas,
+//keep the coordinates of the page they are cut from so the source rectangles are moved to match.
+func ImagePartsFor(img *ebiten.Image, parts ebiten.ImageParts) ebiten.ImageParts {
+	if img == nil || parts == nil {
+		return parts
+	}
+	min := img.Bounds().Min
+	if min.X == 0 && min.Y == 0 {
+		return parts
+	}
+	return offsetImageParts{ImageParts: parts, x: min.X, y: min.Y}
+}
@@ -3,7 +3,6 @@ package tentsuyu
 import (
 	"io"
 	"io/ioutil"
-	"log"
 	"time"
 
 	"github.com/h2non/filetype"
@@ -28,6 +27,8 @@ type AudioPlayer struct {
 	volume128         int
 	songs             map[string]*audio.Player
 	muteSE, muteMusic bool
+	//Input is read by UpdateVolumeIfNeeded, the keyboard is read directly when it is nil
+	Input *InputController
 }
 
 //NewAudioPlayer returns a new AudioPlayer
@@ -37,7 +38,7 @@ func NewAudioPlayer() (*AudioPlayer, error) {
 	if audioContext == nil {
 		audioContext, err = audio.NewContext(sampleRate)
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
 	}
 	//const bytesPerSample = 4
@@ -64,11 +65,12 @@ func (p *AudioPlayer) PlaySE(se string) error {
 	if p.muteSE {
 		return nil
 	}
-	sePlayer, _ := audio.NewPlayerFromBytes(p.audioContext, p.seBytes[se])
+	sePlayer, err := audio.NewPlayerFromBytes(p.audioContext, p.seBytes[se])
+	if err != nil {
+		return err
+	}
 	sePlayer.SetVolume(p.seVolume[se])
-	sePlayer.Play()
-
-	return nil
+	return sePlayer.Play()
 
 }
 
@@ -98,6 +100,22 @@ func (p *AudioPlayer) IsMusicMuted() bool {
 	return p.muteMusic
 }
 
+//RemoveSoundEffect removes the sound effect from the AudioPlayer
+func (p *AudioPlayer) RemoveSoundEffect(name string) {
+	delete(p.seBytes, name)
+	delete(p.seVolume, name)
+}
+
+//RemoveSong stops the song and removes it from the AudioPlayer
+func (p *AudioPlayer) RemoveSong(name string) error {
+	song, ok := p.songs[name]
+	if !ok {
+		return nil
+	}
+	delete(p.songs, name)
+	return song.Close()
+}
+
 //ReturnSongPlayer returns the player for the song audio
 func (p *AudioPlayer) ReturnSongPlayer(name string) *audio.Player {
 	return p.songs[name]
@@ -109,43 +127,46 @@ func (p *AudioPlayer) AddSoundEffectFromFile(name, filelocation string, volume f
 	if err != nil {
 		return err
 	}
-
-	return p.AddSoundEffectFromBytes(name, fb, volume)
+	if err := p.AddSoundEffectFromBytes(name, fb, volume); err != nil {
+		return audioError(filelocation, err)
+	}
+	watchSoundEffect(p, name, filelocation)
+	return nil
 }
 
 //AddSoundEffectFromBytes adds a new sound effect file from a byte slice
 func (p *AudioPlayer) AddSoundEffectFromBytes(name string, fb []byte, volume float64) error {
-	var s io.Reader
-	var err error
-	if filetype.IsExtension(fb, "wav") {
-		s, err = wav.Decode(audioContext, audio.BytesReadSeekCloser(fb))
-		if err != nil {
-			log.Fatal(err)
-
-		}
-	} else if filetype.IsExtension(fb, "mp3") {
-		s, err = mp3.Decode(audioContext, audio.BytesReadSeekCloser(fb))
-		if err != nil {
-			log.Fatal(err)
-
-		}
-	} else if filetype.IsExtension(fb, "ogg") {
-		s, err = vorbis.Decode(audioContext, audio.BytesReadSeekCloser(fb))
-		if err != nil {
-			log.Fatal(err)
-
-		}
-	}
-	b, err := ioutil.ReadAll(s)
+	b, err := decodeSoundEffect(fb)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-
 	p.seBytes[name] = b
 	p.seVolume[name] = volume
 	return nil
 }
 
+//decodeSoundEffect decodes the whole sound effect so it can be played again and again from memory
+func decodeSoundEffect(fb []byte) ([]byte, error) {
+	s, err := decodeAudio(fb)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(s)
+}
+
+//decodeAudio picks the wav, mp3 or ogg decoder from the contents of the file
+func decodeAudio(fb []byte) (io.ReadCloser, error) {
+	switch {
+	case filetype.IsExtension(fb, "wav"):
+		return wav.Decode(audioContext, audio.BytesReadSeekCloser(fb))
+	case filetype.IsExtension(fb, "mp3"):
+		return mp3.Decode(audioContext, audio.BytesReadSeekCloser(fb))
+	case filetype.IsExtension(fb, "ogg"):
+		return vorbis.Decode(audioContext, audio.BytesReadSeekCloser(fb))
+	}
+	return nil, &UnknownFormatError{Kind: "audio", Expected: []string{"wav", "mp3", "ogg"}}
+}
+
 //AddSongFromFile to the AudioPlayer
 func (p *AudioPlayer) AddSongFromFile(name, filelocation string) error {
 
@@ -153,33 +174,12 @@ func (p *AudioPlayer) AddSongFromFile(name, filelocation string) error {
 	if err != nil {
 		return err
 	}
-	return p.AddSongFromBytes(name, fb)
+	return audioError(filelocation, p.AddSongFromBytes(name, fb))
 }
 
 //AddSongFromBytes takes the byte slice of the song file
 func (p *AudioPlayer) AddSongFromBytes(name string, fb []byte) error {
-	var s io.ReadCloser
-	var err error
-	if filetype.IsExtension(fb, "wav") {
-		s, err = wav.Decode(audioContext, audio.BytesReadSeekCloser(fb))
-		if err != nil {
-			log.Fatal(err)
-
-		}
-	} else if filetype.IsExtension(fb, "mp3") {
-		s, err = mp3.Decode(audioContext, audio.BytesReadSeekCloser(fb))
-		if err != nil {
-			log.Fatal(err)
-
-		}
-	} else if filetype.IsExtension(fb, "ogg") {
-		s, err = vorbis.Decode(audioContext, audio.BytesReadSeekCloser(fb))
-		if err != nil {
-			log.Fatal(err)
-
-		}
-	}
-	a, err := audio.NewPlayer(audioContext, s)
+	a, err := newSongPlayer(fb)
 	if err != nil {
 		return err
 	}
@@ -187,6 +187,15 @@ func (p *AudioPlayer) AddSongFromBytes(name string, fb []byte) error {
 	return nil
 }
 
+//newSongPlayer creates a player streaming the song from its bytes
+func newSongPlayer(fb []byte) (*audio.Player, error) {
+	s, err := decodeAudio(fb)
+	if err != nil {
+		return nil, err
+	}
+	return audio.NewPlayer(audioContext, s)
+}
+
 //Update isn't currently used for the AudioPlayer
 //TODO: Implement this ... if necessary
 func (p *AudioPlayer) Update() error {
@@ -207,13 +216,18 @@ func (p *AudioPlayer) Update() error {
 	return nil
 }
 
-//UpdateVolumeIfNeeded should be used to listen to changing the volume level
+//UpdateVolumeIfNeeded should be used to listen to changing the volume level with Z and X on the player's Input
 //TODO: Implement this
 func (p *AudioPlayer) UpdateVolumeIfNeeded() {
-	if ebiten.IsKeyPressed(ebiten.KeyZ) {
+	p.UpdateVolumeFrom(p.Input)
+}
+
+//UpdateVolumeFrom listens to changing the volume level with Z and X on the given InputController
+func (p *AudioPlayer) UpdateVolumeFrom(input *InputController) {
+	if keyDown(input, ebiten.KeyZ) {
 		p.volume128--
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyX) {
+	if keyDown(input, ebiten.KeyX) {
 		p.volume128++
 	}
 	if p.volume128 < 0 {
@@ -71,11 +71,13 @@ func (b *BasicImageParts) Src(i int) (x0, y0, x1, y1 int) {
 
 //SubImage returns the sub image of the passed ebiten.Image based on the BasicImageParts properties
 //Reduces the amount of coding needed in the actual game to get to drawing the image
+//The rectangle is relative to the image, which may itself be a sub-image of an atlas.
 func (b BasicImageParts) SubImage(img *ebiten.Image) *ebiten.Image {
+	min := img.Bounds().Min
 	if b.Reverse {
-		return img.SubImage(image.Rect(b.Sx+b.Width, b.Sy, b.Sx, b.Sy+b.Height)).(*ebiten.Image)
+		return img.SubImage(image.Rect(b.Sx+b.Width, b.Sy, b.Sx, b.Sy+b.Height).Add(min)).(*ebiten.Image)
 	}
-	return img.SubImage(image.Rect(b.Sx, b.Sy, b.Sx+b.Width, b.Sy+b.Height)).(*ebiten.Image)
+	return img.SubImage(image.Rect(b.Sx, b.Sy, b.Sx+b.Width, b.Sy+b.Height).Add(min)).(*ebiten.Image)
 }
 
 //SetScale sets the scale of the DrawImageOptions based on the given DestHeight and DestWidth of the BasicImageParts
@@ -0,0 +1,192 @@
+package tentsuyu
+
+import (
+	"sort"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+//AssetRef names an asset held by an AssetBundle by its kind, such as AssetImage, and the name it is stored under
+type AssetRef struct {
+	Kind string
+	Name string
+}
+
+//AssetBundles counts how many bundles hold each asset of the Game.
+//An asset is removed from the ImageManager, AudioPlayer, UIController, SpriteSheets or TileMaps of the Game,
+//and disposed, when the last bundle holding it is unloaded. Assets added without a bundle are never removed.
+type AssetBundles struct {
+	game    *Game
+	bundles map[string]*AssetBundle
+	refs    map[AssetRef]int
+}
+
+//NewAssetBundles creates the bundles of the Game
+func NewAssetBundles(g *Game) *AssetBundles {
+	return &AssetBundles{
+		game:    g,
+		bundles: map[string]*AssetBundle{},
+		refs:    map[AssetRef]int{},
+	}
+}
+
+//Bundle returns the bundle with the name, creating it if it does not exist yet
+func (ab *AssetBundles) Bundle(name string) *AssetBundle {
+	b, ok := ab.bundles[name]
+	if !ok {
+		b = &AssetBundle{Name: name, refs: map[AssetRef]bool{}, owner: ab}
+		ab.bundles[name] = b
+	}
+	return b
+}
+
+//Loaded returns true if a bundle with the name exists
+func (ab *AssetBundles) Loaded(name string) bool {
+	_, ok := ab.bundles[name]
+	return ok
+}
+
+//References returns the number of bundles holding the asset
+func (ab *AssetBundles) References(kind, name string) int {
+	return ab.refs[AssetRef{kind, name}]
+}
+
+//Unload releases every asset of the bundle and removes the bundle.
+//Assets still held by another bundle are kept. The first error from closing a song is returned.
+func (ab *AssetBundles) Unload(name string) error {
+	b, ok := ab.bundles[name]
+	if !ok {
+		return nil
+	}
+	delete(ab.bundles, name)
+	var firstErr error
+	for _, r := range b.Assets() {
+		ab.refs[r]--
+		if ab.refs[r] > 0 {
+			continue
+		}
+		delete(ab.refs, r)
+		if err := ab.release(r); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	b.refs = map[AssetRef]bool{}
+	return firstErr
+}
+
+//release removes the asset from the Game
+func (ab *AssetBundles) release(r AssetRef) error {
+	g := ab.game
+	switch r.Kind {
+	case AssetImage:
+		g.ImageManager.RemoveImage(r.Name)
+	case AssetSoundEffect:
+		g.AudioPlayer.RemoveSoundEffect(r.Name)
+	case AssetSong:
+		return g.AudioPlayer.RemoveSong(r.Name)
+	case AssetFont:
+		g.UIController.RemoveFont(r.Name)
+	case AssetSpriteSheet:
+		delete(g.SpriteSheets, r.Name)
+	case AssetMap:
+		if tm, ok := g.TileMaps[r.Name]; ok {
+			tm.InvalidateCache()
+			delete(g.TileMaps, r.Name)
+		}
+	}
+	return nil
+}
+
+//AssetBundle is a named group of assets, such as those of one level, that are unloaded together
+type AssetBundle struct {
+	Name  string
+	refs  map[AssetRef]bool
+	owner *AssetBundles
+}
+
+//Add holds the asset of the kind and name in the bundle. Adding the same asset twice holds it once.
+func (b *AssetBundle) Add(kind, name string) {
+	r := AssetRef{kind, name}
+	if b.refs[r] {
+		return
+	}
+	b.refs[r] = true
+	b.owner.refs[r]++
+}
+
+//Has returns true if the bundle holds the asset
+func (b *AssetBundle) Has(kind, name string) bool {
+	return b.refs[AssetRef{kind, name}]
+}
+
+//Assets returns the assets held by the bundle sorted by kind and name
+func (b *AssetBundle) Assets() []AssetRef {
+	refs := make([]AssetRef, 0, len(b.refs))
+	for r := range b.refs {
+		refs = append(refs, r)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Kind != refs[j].Kind {
+			return refs[i].Kind < refs[j].Kind
+		}
+		return refs[i].Name < refs[j].Name
+	})
+	return refs
+}
+
+//Unload releases the assets of the bundle, see AssetBundles.Unload
+func (b *AssetBundle) Unload() error {
+	return b.owner.Unload(b.Name)
+}
+
+//AddImage adds the image to the ImageManager of the Game and holds it in the bundle
+func (b *AssetBundle) AddImage(name string, img *ebiten.Image) {
+	b.owner.game.ImageManager.AddImage(name, img)
+	b.Add(AssetImage, name)
+}
+
+//AddImageFromFile loads the image into the ImageManager of the Game and holds it in the bundle
+func (b *AssetBundle) AddImageFromFile(name, path string) error {
+	if err := b.owner.game.ImageManager.AddImageFromFile(name, path); err != nil {
+		return err
+	}
+	b.Add(AssetImage, name)
+	return nil
+}
+
+//AddSoundEffectFromFile loads the sound effect into the AudioPlayer of the Game and holds it in the bundle
+func (b *AssetBundle) AddSoundEffectFromFile(name, path string, volume float64) error {
+	if err := b.owner.game.AudioPlayer.AddSoundEffectFromFile(name, path, volume); err != nil {
+		return err
+	}
+	b.Add(AssetSoundEffect, name)
+	return nil
+}
+
+//AddSongFromFile loads the song into the AudioPlayer of the Game and holds it in the bundle
+func (b *AssetBundle) AddSongFromFile(name, path string) error {
+	if err := b.owner.game.AudioPlayer.AddSongFromFile(name, path); err != nil {
+		return err
+	}
+	b.Add(AssetSong, name)
+	return nil
+}
+
+//AddFontFile loads the font into the UIController of the Game and holds it in the bundle
+func (b *AssetBundle) AddFontFile(name, path string) error {
+	if err := b.owner.game.UIController.AddFontFile(name, path); err != nil {
+		return err
+	}
+	b.Add(AssetFont, name)
+	return nil
+}
+
+//Bundle returns the AssetBundle with the name, creating it if it does not exist yet
+func (g *Game) Bundle(name string) *AssetBundle {
+	return g.Bundles.Bundle(name)
+}
+
+//UnloadBundle releases the assets of the named AssetBundle that no other bundle holds
+func (g *Game) UnloadBundle(name string) error {
+	return g.Bundles.Unload(name)
+}
@@ -26,6 +26,10 @@ type Camera struct {
 	lowerBoundX, upperBoundX                                       float64
 	lowerBoundY, upperBoundY                                       float64
 	clamp                                                          bool
+	ticks                                                          float64
+	useTicks                                                       bool
+	//Input is read by ChangeZoom and ZoomOut, the keyboard is read directly when it is nil
+	Input *InputController
 }
 
 //CreateCamera intializes a camera struct
@@ -130,8 +134,13 @@ func (c *Camera) GetDestination() (float64, float64) {
 	return c.destX, c.destY
 }
 
-//ChangeZoom increments or decrements the camera zoom level
+//ChangeZoom increments or decrements the camera zoom level while Q or E is held on the camera's Input
 func (c *Camera) ChangeZoom() {
+	c.ChangeZoomFrom(c.Input)
+}
+
+//ChangeZoomFrom increments or decrements the camera zoom level while Q or E is held on the given InputController
+func (c *Camera) ChangeZoomFrom(input *InputController) {
 	if c.zoomCount > 0 {
 		c.zoomCount++
 		if c.zoomCount > c.zoomCountMax {
@@ -139,11 +148,11 @@ func (c *Camera) ChangeZoom() {
 		}
 	} else {
 		increment := 0.01
-		if ebiten.IsKeyPressed(ebiten.KeyQ) && c.Zoom < 2.0 {
+		if keyDown(input, ebiten.KeyQ) && c.Zoom < 2.0 {
 			c.Zoom += increment
 			c.zoomCount++
 		}
-		if ebiten.IsKeyPressed(ebiten.KeyE) && c.Zoom > 0.1 {
+		if keyDown(input, ebiten.KeyE) && c.Zoom > 0.1 {
 			c.Zoom -= increment
 			c.zoomCount++
 		}
@@ -161,8 +170,8 @@ func (c *Camera) moveToDestination() {
 	if c.moving {
 		angle := math.Atan2(c.destY-c.y, c.destX-c.x)
 
-		c.x += c.freeFloatSpeed * math.Cos(angle)
-		c.y += c.freeFloatSpeed * math.Sin(angle)
+		c.x += c.freeFloatSpeed * c.tickScale() * math.Cos(angle)
+		c.y += c.freeFloatSpeed * c.tickScale() * math.Sin(angle)
 
 		if tentsuyutils.Distance(c.x, c.y, c.destX, c.destY) <= 5 {
 			c.moving = false
@@ -201,8 +210,13 @@ func (c *Camera) ZoomIn() {
 	}
 }
 
-//ZoomOut moves the camera further away from the player
+//ZoomOut moves the camera further away from the player while E is held on the camera's Input
 func (c *Camera) ZoomOut() {
+	c.ZoomOutFrom(c.Input)
+}
+
+//ZoomOutFrom moves the camera further away from the player while E is held on the given InputController
+func (c *Camera) ZoomOutFrom(input *InputController) {
 	if c.zoomCount > 0 {
 		c.zoomCount++
 		if c.zoomCount > c.zoomCountMax {
@@ -210,7 +224,7 @@ func (c *Camera) ZoomOut() {
 		}
 	} else {
 		increment := 0.01
-		if ebiten.IsKeyPressed(ebiten.KeyE) && c.Zoom > c.MaxZoomOut {
+		if keyDown(input, ebiten.KeyE) && c.Zoom > c.MaxZoomOut {
 			c.Zoom -= increment
 			c.zoomCount++
 		}
@@ -233,6 +247,15 @@ func (c Camera) OnScreen(x, y float64, w, h int) bool {
 	return containsX && containsY
 }
 
+//VisibleBounds returns the area of the world, before zoom, that is visible through the camera
+func (c Camera) VisibleBounds() (x0, y0, x1, y1 float64) {
+	zoom := c.Zoom
+	if zoom <= 0 {
+		zoom = 1
+	}
+	return c.x / zoom, c.y / zoom, (c.x + c.Width) / zoom, (c.y + c.Height) / zoom
+}
+
 //Position of the camera
 func (c Camera) Position() (x, y float64) {
 	return c.x, c.y
@@ -286,8 +309,29 @@ func (c Camera) ApplyCameraTransform(op *ebiten.DrawImageOptions, applyZoom bool
 	}
 }
 
+//UpdateDelta updates the camera as if dt seconds have passed instead of a single tick.
+//Pass Clock.SubsystemDelta(ClockCamera) to have movement and shaking follow the game's time scale.
+func (c *Camera) UpdateDelta(dt float64) {
+	c.ticks = dt * referenceTPS
+	c.useTicks = true
+	c.update()
+	c.useTicks = false
+}
+
+//tickScale returns how many 60 TPS ticks the current update represents
+func (c *Camera) tickScale() float64 {
+	if !c.useTicks {
+		return 1
+	}
+	return c.ticks
+}
+
 //Update the camera
 func (c *Camera) Update() {
+	c.update()
+}
+
+func (c *Camera) update() {
 	if c.startShaking {
 		c.moving = false
 		if !c.isShaking { //retain the preshake coords
@@ -323,6 +367,15 @@ func (c Camera) GetScreenCoords(x, y float64) (float64, float64) {
 	return x*c.Zoom - c.x, y*c.Zoom - c.y
 }
 
+//ScreenToWorld converts a screen position, such as the mouse, into world coordinates
+func (c Camera) ScreenToWorld(x, y float64) (float64, float64) {
+	zoom := c.Zoom
+	if zoom <= 0 {
+		zoom = 1
+	}
+	return (x + c.x) / zoom, (y + c.y) / zoom
+}
+
 //FollowPlayer follows the specified character (in this case the player)
 func (c *Camera) FollowPlayer(player GameObject, worldWidth, worldHeight float64) {
 
@@ -501,7 +554,7 @@ func (c *Camera) Shake() {
 	}*/
 
 	if c.shakeRadius >= 0.2 {
-		c.shakeRadius *= 0.9
+		c.shakeRadius *= math.Pow(0.9, c.tickScale())
 		c.shakeAngle += (150 + rand.Float64()*1.0472)
 		offsetX := math.Sin(c.shakeAngle) * c.shakeRadius
 		offsetY := math.Cos(c.shakeAngle) * c.shakeRadius
@@ -0,0 +1,184 @@
+package tentsuyu
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+//Clock subsystem names used with SetSubsystemScale and SubsystemDelta
+const (
+	ClockAnimation = "Animation"
+	ClockCamera    = "Camera"
+	ClockParticles = "Particles"
+	ClockTweens    = "Tweens"
+)
+
+//referenceTPS is the tick rate the frame counting parts of tentsuyu were written against
+const referenceTPS = 60
+
+//Clock drives the fixed-timestep game logic of a Game.
+//Each ebiten tick adds its (scaled) duration to an accumulator and the game logic is stepped
+//in FixedStep increments, leaving the remainder available to Draw as an interpolation Alpha.
+//
+//Pause, Hitstop and the time scale only hold back what runs in a step: the GameStateLoop, States.Update,
+//Tweens and GameData. Input polling, the UIController, transitions, Draw and the UpdateRealTime of a
+//GameStateRealTimeUpdater run every tick, so a pause menu can still read input and Resume the clock.
+//Steps read the input since the previous step, a key pressed while no step ran is JustPressed on the next one.
+type Clock struct {
+	FixedStep time.Duration
+	MaxSteps  int
+
+	scale           float64
+	paused          bool
+	hitstop         time.Duration
+	accumulator     time.Duration
+	tickDelta       time.Duration
+	elapsed         time.Duration
+	steps           int
+	subsystemScales map[string]float64
+}
+
+//NewClock returns a Clock stepping at the given fixed step with a time scale of 1
+func NewClock(fixedStep time.Duration) *Clock {
+	return &Clock{
+		FixedStep:       fixedStep,
+		MaxSteps:        5,
+		scale:           1,
+		subsystemScales: map[string]float64{},
+	}
+}
+
+//Tick advances the clock by one ebiten tick and returns how many fixed logic steps should run
+func (c *Clock) Tick() int {
+	tps := ebiten.MaxTPS()
+	if tps <= 0 {
+		tps = referenceTPS
+	}
+	return c.Advance(time.Second / time.Duration(tps))
+}
+
+//Advance moves the clock forward by the passed real time and returns how many fixed logic steps should run.
+//Tick calls this with the duration of a single ebiten tick.
+func (c *Clock) Advance(real time.Duration) int {
+	c.tickDelta = real
+	if c.FixedStep <= 0 {
+		c.FixedStep = time.Second / referenceTPS
+	}
+	scaled := time.Duration(float64(real) * c.scale)
+	if c.hitstop > 0 {
+		c.hitstop -= real
+		scaled = 0
+		if c.hitstop < 0 {
+			c.hitstop = 0
+		}
+	}
+	if c.paused {
+		scaled = 0
+	}
+	c.accumulator += scaled
+
+	steps := 0
+	for c.accumulator >= c.FixedStep {
+		c.accumulator -= c.FixedStep
+		c.elapsed += c.FixedStep
+		steps++
+		if c.MaxSteps > 0 && steps >= c.MaxSteps {
+			//Drop the backlog instead of spiralling further behind
+			if c.accumulator > c.FixedStep {
+				c.accumulator = c.FixedStep - 1
+			}
+			break
+		}
+	}
+	c.steps = steps
+	return steps
+}
+
+//Alpha returns how far between the last and next fixed logic step the clock currently is (0 to 1).
+//Draw code can use it to interpolate between the previous and current positions of objects.
+func (c *Clock) Alpha() float64 {
+	if c.FixedStep <= 0 {
+		return 0
+	}
+	return float64(c.accumulator) / float64(c.FixedStep)
+}
+
+//Steps returns how many fixed logic steps were run on the last tick
+func (c *Clock) Steps() int {
+	return c.steps
+}
+
+//Delta returns the duration of a single fixed logic step in seconds
+func (c *Clock) Delta() float64 {
+	return c.FixedStep.Seconds()
+}
+
+//TickDelta returns the unscaled real duration of the last tick in seconds.
+//This is useful for things that should keep moving while the game is paused or slowed down.
+func (c *Clock) TickDelta() float64 {
+	return c.tickDelta.Seconds()
+}
+
+//Elapsed returns the total scaled game time that has been simulated
+func (c *Clock) Elapsed() time.Duration {
+	return c.elapsed
+}
+
+//SetTimeScale sets the global speed of the game clock, 1 being normal speed and 0.5 half speed
+func (c *Clock) SetTimeScale(scale float64) {
+	if scale < 0 {
+		scale = 0
+	}
+	c.scale = scale
+}
+
+//TimeScale returns the global speed of the game clock
+func (c *Clock) TimeScale() float64 {
+	return c.scale
+}
+
+//Pause stops game time from advancing
+func (c *Clock) Pause() {
+	c.paused = true
+}
+
+//Resume allows game time to advance after a Pause
+func (c *Clock) Resume() {
+	c.paused = false
+}
+
+//Paused returns true if the clock is paused
+func (c *Clock) Paused() bool {
+	return c.paused
+}
+
+//Hitstop freezes game time for the passed real duration, commonly used to give weight to hits
+func (c *Clock) Hitstop(d time.Duration) {
+	if d > c.hitstop {
+		c.hitstop = d
+	}
+}
+
+//InHitstop returns true while the clock is frozen by Hitstop
+func (c *Clock) InHitstop() bool {
+	return c.hitstop > 0
+}
+
+//SetSubsystemScale sets an additional time scale for the named subsystem (e.g. ClockAnimation)
+func (c *Clock) SetSubsystemScale(name string, scale float64) {
+	c.subsystemScales[name] = scale
+}
+
+//SubsystemScale returns the time scale of the named subsystem, 1 if it was never set
+func (c *Clock) SubsystemScale(name string) float64 {
+	if scale, ok := c.subsystemScales[name]; ok {
+		return scale
+	}
+	return 1
+}
+
+//SubsystemDelta returns the seconds a fixed logic step lasts for the named subsystem
+func (c *Clock) SubsystemDelta(name string) float64 {
+	return c.Delta() * c.SubsystemScale(name)
+}
@@ -0,0 +1,48 @@
+//Command tentsuyuatlas packs a directory of images into atlas pages for ImageManager.AddAtlasFromFile.
+//
+//	tentsuyuatlas -in sprites -out assets/atlas -size 2048 -padding 2
+//
+//writes assets/atlas-0.png and assets/atlas-0.json, and further pages if the sprites do not fit on one.
+//Sprites are named by their path inside the input directory without the extension.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/atolVerderben/tentsuyu/tentsuyuatlas"
+)
+
+func main() {
+	in := flag.String("in", ".", "directory of png and jpg images to pack")
+	out := flag.String("out", "atlas", "path and name of the pages to write, without extension")
+	size := flag.Int("size", 2048, "maximum width and height of a page")
+	padding := flag.Int("padding", 1, "empty pixels between sprites")
+	pot := flag.Bool("pot", false, "round page sizes up to a power of two")
+	flag.Parse()
+
+	sprites, err := tentsuyuatlas.LoadDir(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(sprites) == 0 {
+		log.Fatalf("no images found in %s", *in)
+	}
+	pages, err := tentsuyuatlas.Pack(sprites, tentsuyuatlas.Options{
+		MaxWidth:   *size,
+		MaxHeight:  *size,
+		Padding:    *padding,
+		PowerOfTwo: *pot,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := tentsuyuatlas.Write(pages, *out); err != nil {
+		log.Fatal(err)
+	}
+	for i, p := range pages {
+		b := p.Image.Bounds()
+		fmt.Printf("%s-%d.png: %d sprites, %dx%d\n", *out, i, len(p.Regions), b.Dx(), b.Dy())
+	}
+}
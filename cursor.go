@@ -53,7 +53,7 @@ func (c *Cursor) SetStyle(cursorstyle int) {
 func (c *Cursor) Draw(screen *ebiten.Image) error {
 	w, h := c.GetSize()
 	op := &ebiten.DrawImageOptions{}
-	op.ImageParts = c.BasicImageParts
+	op.ImageParts = ImagePartsFor(c.spritesheet, c.BasicImageParts)
 	if !c.NotCentered {
 		op.GeoM.Translate(-float64(w)/2, -float64(h)/2)
 	}
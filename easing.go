@@ -0,0 +1,278 @@
+package tentsuyu
+
+import "math"
+
+//EaseFunc maps the progress of a tween, from 0 to 1, to the eased progress.
+//Eased values may go past 0 and 1, such as with the Back and Elastic curves.
+type EaseFunc func(t float64) float64
+
+//Linear does not ease, the value changes at a constant rate
+func Linear(t float64) float64 {
+	return t
+}
+
+//EaseInQuad accelerates from zero velocity
+func EaseInQuad(t float64) float64 {
+	return t * t
+}
+
+//EaseOutQuad decelerates to zero velocity
+func EaseOutQuad(t float64) float64 {
+	return t * (2 - t)
+}
+
+//EaseInOutQuad accelerates until halfway, then decelerates
+func EaseInOutQuad(t float64) float64 {
+	if t < 0.5 {
+		return 2 * t * t
+	}
+	return -1 + (4-2*t)*t
+}
+
+//EaseInCubic accelerates from zero velocity
+func EaseInCubic(t float64) float64 {
+	return t * t * t
+}
+
+//EaseOutCubic decelerates to zero velocity
+func EaseOutCubic(t float64) float64 {
+	t--
+	return t*t*t + 1
+}
+
+//EaseInOutCubic accelerates until halfway, then decelerates
+func EaseInOutCubic(t float64) float64 {
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+	t = 2*t - 2
+	return t*t*t/2 + 1
+}
+
+//EaseInQuart accelerates from zero velocity
+func EaseInQuart(t float64) float64 {
+	return t * t * t * t
+}
+
+//EaseOutQuart decelerates to zero velocity
+func EaseOutQuart(t float64) float64 {
+	t--
+	return 1 - t*t*t*t
+}
+
+//EaseInOutQuart accelerates until halfway, then decelerates
+func EaseInOutQuart(t float64) float64 {
+	if t < 0.5 {
+		return 8 * t * t * t * t
+	}
+	t--
+	return 1 - 8*t*t*t*t
+}
+
+//EaseInQuint accelerates from zero velocity
+func EaseInQuint(t float64) float64 {
+	return t * t * t * t * t
+}
+
+//EaseOutQuint decelerates to zero velocity
+func EaseOutQuint(t float64) float64 {
+	t--
+	return t*t*t*t*t + 1
+}
+
+//EaseInOutQuint accelerates until halfway, then decelerates
+func EaseInOutQuint(t float64) float64 {
+	if t < 0.5 {
+		return 16 * t * t * t * t * t
+	}
+	t = 2*t - 2
+	return t*t*t*t*t/2 + 1
+}
+
+//EaseInSine accelerates from zero velocity along a sine curve
+func EaseInSine(t float64) float64 {
+	return 1 - math.Cos(t*math.Pi/2)
+}
+
+//EaseOutSine decelerates to zero velocity along a sine curve
+func EaseOutSine(t float64) float64 {
+	return math.Sin(t * math.Pi / 2)
+}
+
+//EaseInOutSine accelerates until halfway, then decelerates, along a sine curve
+func EaseInOutSine(t float64) float64 {
+	return -(math.Cos(math.Pi*t) - 1) / 2
+}
+
+//EaseInExpo accelerates exponentially from zero velocity
+func EaseInExpo(t float64) float64 {
+	if t <= 0 {
+		return 0
+	}
+	return math.Pow(2, 10*(t-1))
+}
+
+//EaseOutExpo decelerates exponentially to zero velocity
+func EaseOutExpo(t float64) float64 {
+	if t >= 1 {
+		return 1
+	}
+	return 1 - math.Pow(2, -10*t)
+}
+
+//EaseInOutExpo accelerates exponentially until halfway, then decelerates
+func EaseInOutExpo(t float64) float64 {
+	switch {
+	case t <= 0:
+		return 0
+	case t >= 1:
+		return 1
+	case t < 0.5:
+		return math.Pow(2, 20*t-10) / 2
+	}
+	return (2 - math.Pow(2, -20*t+10)) / 2
+}
+
+//EaseInCirc accelerates from zero velocity along a quarter circle
+func EaseInCirc(t float64) float64 {
+	return 1 - math.Sqrt(1-t*t)
+}
+
+//EaseOutCirc decelerates to zero velocity along a quarter circle
+func EaseOutCirc(t float64) float64 {
+	t--
+	return math.Sqrt(1 - t*t)
+}
+
+//EaseInOutCirc accelerates until halfway, then decelerates, along quarter circles
+func EaseInOutCirc(t float64) float64 {
+	if t < 0.5 {
+		return (1 - math.Sqrt(1-4*t*t)) / 2
+	}
+	t = 2*t - 2
+	return (math.Sqrt(1-t*t) + 1) / 2
+}
+
+//backOvershoot is how far the Back curves go past their ends, about 10%
+const backOvershoot = 1.70158
+
+//EaseInBack pulls back before accelerating
+func EaseInBack(t float64) float64 {
+	return t * t * ((backOvershoot+1)*t - backOvershoot)
+}
+
+//EaseOutBack overshoots the end before settling on it
+func EaseOutBack(t float64) float64 {
+	t--
+	return t*t*((backOvershoot+1)*t+backOvershoot) + 1
+}
+
+//EaseInOutBack pulls back at the start and overshoots the end
+func EaseInOutBack(t float64) float64 {
+	s := backOvershoot * 1.525
+	if t < 0.5 {
+		t *= 2
+		return t * t * ((s+1)*t - s) / 2
+	}
+	t = 2*t - 2
+	return (t*t*((s+1)*t+s) + 2) / 2
+}
+
+//EaseInElastic winds up like a spring before accelerating
+func EaseInElastic(t float64) float64 {
+	if t <= 0 || t >= 1 {
+		return math.Max(0, math.Min(1, t))
+	}
+	return -math.Pow(2, 10*t-10) * math.Sin((t*10-10.75)*2*math.Pi/3)
+}
+
+//EaseOutElastic springs past the end and oscillates until it settles
+func EaseOutElastic(t float64) float64 {
+	if t <= 0 || t >= 1 {
+		return math.Max(0, math.Min(1, t))
+	}
+	return math.Pow(2, -10*t)*math.Sin((t*10-0.75)*2*math.Pi/3) + 1
+}
+
+//EaseInOutElastic winds up at the start and springs at the end
+func EaseInOutElastic(t float64) float64 {
+	if t <= 0 || t >= 1 {
+		return math.Max(0, math.Min(1, t))
+	}
+	if t < 0.5 {
+		return -math.Pow(2, 20*t-10) * math.Sin((20*t-11.125)*2*math.Pi/4.5) / 2
+	}
+	return math.Pow(2, -20*t+10)*math.Sin((20*t-11.125)*2*math.Pi/4.5)/2 + 1
+}
+
+//EaseInBounce bounces with growing height before leaving the start
+func EaseInBounce(t float64) float64 {
+	return 1 - EaseOutBounce(1-t)
+}
+
+//EaseOutBounce bounces to a stop on the end like a dropped ball
+func EaseOutBounce(t float64) float64 {
+	const n, d = 7.5625, 2.75
+	switch {
+	case t < 1/d:
+		return n * t * t
+	case t < 2/d:
+		t -= 1.5 / d
+		return n*t*t + 0.75
+	case t < 2.5/d:
+		t -= 2.25 / d
+		return n*t*t + 0.9375
+	}
+	t -= 2.625 / d
+	return n*t*t + 0.984375
+}
+
+//EaseInOutBounce bounces away from the start and onto the end
+func EaseInOutBounce(t float64) float64 {
+	if t < 0.5 {
+		return (1 - EaseOutBounce(1-2*t)) / 2
+	}
+	return (1 + EaseOutBounce(2*t-1)) / 2
+}
+
+//easings are the EaseFuncs by the names used in data files
+var easings = map[string]EaseFunc{
+	"Linear":           Linear,
+	"EaseInQuad":       EaseInQuad,
+	"EaseOutQuad":      EaseOutQuad,
+	"EaseInOutQuad":    EaseInOutQuad,
+	"EaseInCubic":      EaseInCubic,
+	"EaseOutCubic":     EaseOutCubic,
+	"EaseInOutCubic":   EaseInOutCubic,
+	"EaseInQuart":      EaseInQuart,
+	"EaseOutQuart":     EaseOutQuart,
+	"EaseInOutQuart":   EaseInOutQuart,
+	"EaseInQuint":      EaseInQuint,
+	"EaseOutQuint":     EaseOutQuint,
+	"EaseInOutQuint":   EaseInOutQuint,
+	"EaseInSine":       EaseInSine,
+	"EaseOutSine":      EaseOutSine,
+	"EaseInOutSine":    EaseInOutSine,
+	"EaseInExpo":       EaseInExpo,
+	"EaseOutExpo":      EaseOutExpo,
+	"EaseInOutExpo":    EaseInOutExpo,
+	"EaseInCirc":       EaseInCirc,
+	"EaseOutCirc":      EaseOutCirc,
+	"EaseInOutCirc":    EaseInOutCirc,
+	"EaseInBack":       EaseInBack,
+	"EaseOutBack":      EaseOutBack,
+	"EaseInOutBack":    EaseInOutBack,
+	"EaseInElastic":    EaseInElastic,
+	"EaseOutElastic":   EaseOutElastic,
+	"EaseInOutElastic": EaseInOutElastic,
+	"EaseInBounce":     EaseInBounce,
+	"EaseOutBounce":    EaseOutBounce,
+	"EaseInOutBounce":  EaseInOutBounce,
+}
+
+//EaseByName returns the EaseFunc with the name of its function, such as "EaseOutQuad", for easings read from data files.
+//It returns false for an unknown name.
+func EaseByName(name string) (EaseFunc, bool) {
+	e, ok := easings[name]
+	return e, ok
+}
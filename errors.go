@@ -0,0 +1,90 @@
+package tentsuyu
+
+import (
+	"encoding/json"
+	"errors"
+	"image"
+	"strings"
+)
+
+//UnknownFormatError is returned for a file that is not in a format tentsuyu can decode.
+//Kind is the kind of asset, such as "image" or "audio", and Path is empty when decoding a byte slice.
+type UnknownFormatError struct {
+	Kind     string
+	Path     string
+	Expected []string
+}
+
+func (e *UnknownFormatError) Error() string {
+	msg := "tentsuyu: "
+	if e.Path != "" {
+		msg += e.Path + ": "
+	}
+	msg += "unknown " + e.Kind + " format"
+	switch n := len(e.Expected); {
+	case n == 1:
+		msg += ", expected " + e.Expected[0]
+	case n > 1:
+		msg += ", expected " + strings.Join(e.Expected[:n-1], ", ") + " or " + e.Expected[n-1]
+	}
+	return msg
+}
+
+//MalformedJSONError is returned when a JSON file, such as a map or sprite sheet, cannot be decoded.
+//Err is the *json.SyntaxError or *json.UnmarshalTypeError describing the problem.
+type MalformedJSONError struct {
+	Path string
+	Err  error
+}
+
+func (e *MalformedJSONError) Error() string {
+	if e.Path == "" {
+		return "tentsuyu: malformed JSON: " + e.Err.Error()
+	}
+	return "tentsuyu: " + e.Path + ": malformed JSON: " + e.Err.Error()
+}
+
+//Unwrap returns the error of the JSON decoder
+func (e *MalformedJSONError) Unwrap() error {
+	return e.Err
+}
+
+//MissingTileSetError is returned when an external tileset referenced by a map cannot be loaded
+type MissingTileSetError struct {
+	Map    string
+	Source string
+	Err    error
+}
+
+func (e *MissingTileSetError) Error() string {
+	return "tentsuyu: " + e.Map + ": missing tileset " + e.Source + ": " + e.Err.Error()
+}
+
+//Unwrap returns the error from reading the tileset
+func (e *MissingTileSetError) Unwrap() error {
+	return e.Err
+}
+
+//unmarshalJSON decodes the JSON read from path into v, returning a MalformedJSONError on failure
+func unmarshalJSON(path string, raw []byte, v interface{}) error {
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &MalformedJSONError{Path: path, Err: err}
+	}
+	return nil
+}
+
+//imageError turns the unknown format error of the image package into an UnknownFormatError
+func imageError(path string, err error) error {
+	if errors.Is(err, image.ErrFormat) {
+		return &UnknownFormatError{Kind: "image", Path: path}
+	}
+	return err
+}
+
+//audioError sets the path of an UnknownFormatError from decoding audio
+func audioError(path string, err error) error {
+	if e, ok := err.(*UnknownFormatError); ok && e.Path == "" {
+		return &UnknownFormatError{Kind: e.Kind, Path: path, Expected: e.Expected}
+	}
+	return err
+}
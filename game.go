@@ -1,8 +1,8 @@
 package tentsuyu
 
 import (
-	"log"
 	"math/rand"
+	"time"
 
 	"github.com/hajimehoshi/ebiten"
 	"github.com/hajimehoshi/ebiten/ebitenutil"
@@ -24,7 +24,7 @@ type GameLoadAudioFunction func() *AudioPlayer
 type Game struct {
 	imageLoadedCh             chan *ImageManager
 	audioLoadedCh             chan *AudioPlayer
-	gameState                 GameState
+	States                    *GameStateStack
 	PausedState               GameState
 	GameData                  *GameData
 	Screen                    *ebiten.Image
@@ -32,13 +32,24 @@ type Game struct {
 	UIController              *UIController
 	Random                    *rand.Rand
 	Input                     *InputController
+	Clock                     *Clock
 	ImageManager              *ImageManager
 	GameStateLoop             GameHelperFunction
 	GameDrawLoop              GameDrawHelperFunction
 	AudioPlayer               *AudioPlayer
+	SpriteSheets              map[string]*SpriteSheet
+	TileMaps                  map[string]*TileMap
+	Bundles                   *AssetBundles
+	Tweens                    *TweenManager
 	AdditionalCameras         map[string]*Camera
 	IsMobile                  bool
 	screenWidth, screenHeight int
+	pendingErr                error
+	transition                *Transition
+	//OnError is called with every error from a GameState, Transition, the GameStateLoop or GameDrawLoop.
+	//Return nil to keep the game running or an error to stop it, which Update then returns.
+	//Errors raised while drawing reach OnError on the next Update.
+	OnError func(err error) error
 }
 
 //NewGame returns a new Game while setting the width and height of the screen
@@ -49,16 +60,27 @@ func NewGame(screenWidth, screenHeight float64) (game *Game, err error) {
 		GameData:      NewGameData(),
 		//Random:            rand.New(rand.NewSource(time.Now().UnixNano())),
 		Input:             NewInputController(),
+		Clock:             NewClock(time.Second / referenceTPS),
 		DefaultCamera:     CreateCamera(screenWidth, screenHeight),
 		ImageManager:      NewImageManager(),
 		AdditionalCameras: map[string]*Camera{},
+		SpriteSheets:      map[string]*SpriteSheet{},
+		TileMaps:          map[string]*TileMap{},
+		States:            NewGameStateStack(),
+		Tweens:            NewTweenManager(),
 	}
 	game.screenWidth = int(screenWidth)
 	game.screenHeight = int(screenHeight)
 	game.UIController = NewUIController(game.Input)
+	game.Bundles = NewAssetBundles(game)
 	game.AudioPlayer, err = NewAudioPlayer()
-	game.gameState = NewBaseGameState()
-	game.gameState.SetMsg(GameStateMsgNotStarted)
+	if game.AudioPlayer != nil {
+		game.AudioPlayer.Input = game.Input
+	}
+	game.DefaultCamera.Input = game.Input
+	baseState := NewBaseGameState()
+	baseState.SetMsg(GameStateMsgNotStarted)
+	game.States.Push(game, baseState, false, false)
 
 	game.SetGameDrawLoop(func(screen *ebiten.Image) error {
 
@@ -111,13 +133,26 @@ func (g *Game) ToggleFullscreen() {
 
 // Update proceeds the game state.
 // Update is called every tick (1/60 [s] by default).
+// The GameStates are updated in fixed steps of Clock.FixedStep, which may be zero or several times per tick
+// depending on the Clock's time scale.
+// Any error is passed to OnError before being returned.
 func (g *Game) Update(screen *ebiten.Image) error {
+	if err := g.update(screen); err != nil {
+		return g.handleError(err)
+	}
+	return nil
+}
+
+func (g *Game) update(screen *ebiten.Image) error {
 	if g.imageLoadedCh != nil || g.audioLoadedCh != nil {
 		select {
 		case g.ImageManager = <-g.imageLoadedCh:
 
 			g.imageLoadedCh = nil
 		case g.AudioPlayer = <-g.audioLoadedCh:
+			if g.AudioPlayer != nil && g.AudioPlayer.Input == nil {
+				g.AudioPlayer.Input = g.Input
+			}
 
 			g.audioLoadedCh = nil
 		default:
@@ -127,20 +162,56 @@ func (g *Game) Update(screen *ebiten.Image) error {
 		return ebitenutil.DebugPrint(screen, "Now Loading...")
 	}
 
+	if hr := activeHotReloader(); hr != nil {
+		hr.Update()
+	}
+
 	g.Input.Update()
+	steps := g.Clock.Tick()
+	g.UIController.Update()
+	if g.Input.Button("ToggleFullscreen").JustPressed() {
+		g.ToggleFullscreen()
+	}
 
+	if err := g.takePendingErr(); err != nil {
+		return err
+	}
+
+	if g.transition != nil {
+		g.updateTransition()
+		//Input made during a transition is dropped rather than replayed to the first step after it
+		g.Input.endStep()
+		return nil
+	}
+	if err := g.States.UpdateRealTime(g); err != nil {
+		return err
+	}
+	for i := 0; i < steps; i++ {
+		g.Input.beginStep()
+		err := g.step()
+		g.Input.endStep()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//step runs a single fixed timestep of game logic
+func (g *Game) step() error {
 	if err := g.GameStateLoop(); err != nil {
 		return err
 	}
 
-	if err := g.gameState.Update(g); err != nil {
+	if err := g.takePendingErr(); err != nil {
 		return err
 	}
-	g.GameData.Update()
-	g.UIController.Update()
-	if g.Input.Button("ToggleFullscreen").JustPressed() {
-		g.ToggleFullscreen()
+
+	if err := g.States.Update(g); err != nil {
+		return err
 	}
+	g.Tweens.Update(g.Clock.SubsystemDelta(ClockTweens))
+	g.GameData.Update()
 	return nil
 }
 
@@ -149,16 +220,19 @@ func (g *Game) Update(screen *ebiten.Image) error {
 func (g *Game) Draw(screen *ebiten.Image) {
 	g.Screen = screen
 
-	if err := g.gameState.Draw(g); err != nil {
-		log.Fatal(err)
+	var err error
+	if g.transition != nil {
+		err = g.transition.Draw(g, screen)
+	} else {
+		err = g.States.Draw(g)
 	}
 	/*if err := g.UIController.Draw(g.Screen); err != nil {
 		log.Fatal(err)
 	}*/
-	if err := g.GameDrawLoop(screen); err != nil {
-		log.Fatal(err)
+	if err == nil {
+		err = g.GameDrawLoop(screen)
 	}
-
+	g.deferError(err)
 }
 
 // Layout takes the outside size (e.g., the window size) and returns the (logical) screen size.
@@ -171,7 +245,9 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeigh
 //This is for backwards compatibility with older ebiten
 func (g *Game) Loop(screen *ebiten.Image) error {
 
-	g.Update(screen)
+	if err := g.Update(screen); err != nil {
+		return err
+	}
 	if !ebiten.IsDrawingSkipped() {
 		g.Draw(screen)
 	}
@@ -186,27 +262,135 @@ func (g *Game) SetMobile(m bool) {
 }
 
 //SetGameState of the game
+//This clears the stack, including any pause state pushed by SetPauseState, and makes gs the only GameState.
+//Any error from an Exit or Enter is returned by the next Update.
 func (g *Game) SetGameState(gs GameState) {
-	g.gameState = gs
+	g.deferError(g.States.Clear(g))
+	g.deferError(g.States.Push(g, gs, false, false))
 }
 
 //GetGameState of the game
+//This is the GameState on top of the stack
 func (g Game) GetGameState() GameState {
-	return g.gameState
+	return g.States.Top()
+}
+
+//PushGameState places the GameState on top of the stack, hiding and freezing the states below it
+func (g *Game) PushGameState(gs GameState) error {
+	return g.States.Push(g, gs, false, false)
+}
+
+//PushOverlayState places the GameState on top of the stack.
+//drawBelow and updateBelow determine whether the states underneath continue to be drawn and updated.
+func (g *Game) PushOverlayState(gs GameState, drawBelow, updateBelow bool) error {
+	return g.States.Push(g, gs, drawBelow, updateBelow)
+}
+
+//PopGameState removes the GameState on top of the stack and returns it.
+//ErrPopLastGameState is returned rather than leaving the Game without a GameState.
+func (g *Game) PopGameState() (GameState, error) {
+	return g.States.Pop(g)
+}
+
+//ReplaceGameState swaps the GameState on top of the stack for the passed GameState
+func (g *Game) ReplaceGameState(gs GameState) error {
+	_, err := g.States.Replace(g, gs)
+	return err
 }
 
 //SetPauseState of the game
-//This changes the PausedState to the current GameState then switches to the passed GameState.
+//This changes the PausedState to the current GameState then pushes the passed GameState over it.
 //Used to preserve the current game state
 func (g *Game) SetPauseState(gs GameState) {
-	g.PausedState = g.gameState
-	g.gameState = gs
+	g.PausedState = g.States.Top()
+	if err := g.States.Push(g, gs, false, false); err != nil {
+		g.deferError(err)
+	}
 	g.PausedState.SetMsg(GameStateMsgNone)
 }
 
 //UnPause switches back the the puasedState GameState of the Game
 func (g *Game) UnPause() {
-	g.gameState = g.PausedState
+	if g.States.Len() > 1 {
+		if _, err := g.States.Pop(g); err != nil {
+			g.deferError(err)
+		}
+		return
+	}
+	if g.PausedState != nil {
+		g.SetGameState(g.PausedState)
+	}
+}
+
+//TransitionToGameState replaces the GameState on top of the stack with gs, animating the change with the passed Transition.
+//The outgoing frame, including states drawn below the top, is captured before the old state exits,
+//so states releasing resources in Exit are never drawn after it.
+//Neither GameState nor the GameStateLoop is updated until the transition completes.
+func (g *Game) TransitionToGameState(gs GameState, t *Transition) error {
+	if g.transition != nil {
+		g.finishTransition()
+	}
+	if err := t.start(g.screenWidth, g.screenHeight); err != nil {
+		t.dispose()
+		return err
+	}
+	//Capture the outgoing frame while its states are still alive, Replace exits them
+	if err := t.capture(g); err != nil {
+		t.dispose()
+		return err
+	}
+	if _, err := g.States.Replace(g, gs); err != nil {
+		t.dispose()
+		return err
+	}
+	g.transition = t
+	return nil
+}
+
+//InTransition returns true while a Transition between GameStates is playing
+func (g *Game) InTransition() bool {
+	return g.transition != nil
+}
+
+//updateTransition advances the current Transition by one tick
+func (g *Game) updateTransition() {
+	g.transition.update(g.Clock.tickDelta)
+	if g.transition.Done() {
+		g.finishTransition()
+	}
+}
+
+//finishTransition releases the current Transition and calls its OnComplete
+func (g *Game) finishTransition() {
+	t := g.transition
+	g.transition = nil
+	t.dispose()
+	if t.OnComplete != nil {
+		t.OnComplete()
+	}
+}
+
+//deferError keeps the first error raised outside of Update, such as while drawing or changing states,
+//for the next Update to return
+func (g *Game) deferError(err error) {
+	if err != nil && g.pendingErr == nil {
+		g.pendingErr = err
+	}
+}
+
+//takePendingErr returns and clears the error kept by deferError
+func (g *Game) takePendingErr() error {
+	err := g.pendingErr
+	g.pendingErr = nil
+	return err
+}
+
+//handleError passes the error to OnError when it is set
+func (g *Game) handleError(err error) error {
+	if g.OnError != nil {
+		return g.OnError(err)
+	}
+	return err
 }
 
 //SetGameStateLoop should be a switch statement telling the game when to switch to what gamestate
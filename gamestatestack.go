@@ -0,0 +1,244 @@
+package tentsuyu
+
+import "errors"
+
+//ErrPopLastGameState is returned when popping the only GameState, the Game always needs one to update and draw
+var ErrPopLastGameState = errors.New("tentsuyu: cannot pop the last GameState")
+
+//GameStateEnterer is implemented by a GameState that wants to know when it is placed on the stack
+type GameStateEnterer interface {
+	Enter(*Game) error
+}
+
+//GameStateExiter is implemented by a GameState that wants to know when it is removed from the stack
+type GameStateExiter interface {
+	Exit(*Game) error
+}
+
+//GameStateSuspender is implemented by a GameState that wants to know when another state is pushed over it
+type GameStateSuspender interface {
+	Suspend(*Game) error
+}
+
+//GameStateResumer is implemented by a GameState that wants to know when it becomes the top of the stack again
+type GameStateResumer interface {
+	Resume(*Game) error
+}
+
+//GameStateRealTimeUpdater is implemented by a GameState that needs to run every tick even while the Clock
+//runs no logic steps, such as a pause menu that calls Clock.Resume. UpdateRealTime sees the input of the current tick.
+type GameStateRealTimeUpdater interface {
+	UpdateRealTime(*Game) error
+}
+
+//StackedGameState is a GameState as it sits within the GameStateStack.
+//DrawBelow and UpdateBelow tell the stack whether the state underneath should still be drawn or updated
+//while this state is above it (e.g. a translucent pause menu over gameplay).
+type StackedGameState struct {
+	State       GameState
+	DrawBelow   bool
+	UpdateBelow bool
+}
+
+//GameStateStack holds the GameStates of the game with the active state on top
+type GameStateStack struct {
+	states []*StackedGameState
+}
+
+//NewGameStateStack returns an empty GameStateStack
+func NewGameStateStack() *GameStateStack {
+	return &GameStateStack{
+		states: []*StackedGameState{},
+	}
+}
+
+//Len returns the number of GameStates on the stack
+func (s *GameStateStack) Len() int {
+	return len(s.states)
+}
+
+//Top returns the GameState on top of the stack or nil if the stack is empty
+func (s *GameStateStack) Top() GameState {
+	if len(s.states) == 0 {
+		return nil
+	}
+	return s.states[len(s.states)-1].State
+}
+
+//TopEntry returns the StackedGameState on top of the stack or nil if the stack is empty
+func (s *GameStateStack) TopEntry() *StackedGameState {
+	if len(s.states) == 0 {
+		return nil
+	}
+	return s.states[len(s.states)-1]
+}
+
+//States returns a copy of the GameStates from the bottom of the stack to the top
+func (s *GameStateStack) States() []GameState {
+	states := make([]GameState, len(s.states))
+	for i := range s.states {
+		states[i] = s.states[i].State
+	}
+	return states
+}
+
+//Push places the GameState on top of the stack.
+//The previous top is suspended and the new state is entered.
+func (s *GameStateStack) Push(g *Game, gs GameState, drawBelow, updateBelow bool) error {
+	if top := s.Top(); top != nil {
+		if suspender, ok := top.(GameStateSuspender); ok {
+			if err := suspender.Suspend(g); err != nil {
+				return err
+			}
+		}
+	}
+	s.states = append(s.states, &StackedGameState{
+		State:       gs,
+		DrawBelow:   drawBelow,
+		UpdateBelow: updateBelow,
+	})
+	if enterer, ok := gs.(GameStateEnterer); ok {
+		return enterer.Enter(g)
+	}
+	return nil
+}
+
+//Pop removes the top GameState from the stack and returns it.
+//The removed state is exited and the new top is resumed.
+//The last state is never popped, ErrPopLastGameState is returned instead. Use Replace or Clear to change it.
+func (s *GameStateStack) Pop(g *Game) (GameState, error) {
+	if len(s.states) == 0 {
+		return nil, nil
+	}
+	if len(s.states) == 1 {
+		return nil, ErrPopLastGameState
+	}
+	top := s.states[len(s.states)-1].State
+	s.states[len(s.states)-1] = nil
+	s.states = s.states[:len(s.states)-1]
+	if exiter, ok := top.(GameStateExiter); ok {
+		if err := exiter.Exit(g); err != nil {
+			return top, err
+		}
+	}
+	if next := s.Top(); next != nil {
+		if resumer, ok := next.(GameStateResumer); ok {
+			if err := resumer.Resume(g); err != nil {
+				return top, err
+			}
+		}
+	}
+	return top, nil
+}
+
+//Replace swaps the top GameState for the passed one without resuming the states below.
+//The replaced state is exited and the new state is entered, keeping the draw and update settings of the old top.
+//If the old state fails to exit the stack is left as it was.
+func (s *GameStateStack) Replace(g *Game, gs GameState) (GameState, error) {
+	if len(s.states) == 0 {
+		return nil, s.Push(g, gs, false, false)
+	}
+	entry := s.states[len(s.states)-1]
+	old := entry.State
+	if exiter, ok := old.(GameStateExiter); ok {
+		if err := exiter.Exit(g); err != nil {
+			return old, err
+		}
+	}
+	entry.State = gs
+	if enterer, ok := gs.(GameStateEnterer); ok {
+		if err := enterer.Enter(g); err != nil {
+			return old, err
+		}
+	}
+	return old, nil
+}
+
+//Clear exits and removes every GameState on the stack from top to bottom.
+//Every state is removed even if one fails to exit, the first error is returned.
+func (s *GameStateStack) Clear(g *Game) error {
+	var first error
+	for len(s.states) > 0 {
+		top := s.states[len(s.states)-1].State
+		s.states[len(s.states)-1] = nil
+		s.states = s.states[:len(s.states)-1]
+		if exiter, ok := top.(GameStateExiter); ok {
+			if err := exiter.Exit(g); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+//lowestUpdated returns the index of the lowest state that should be updated
+func (s *GameStateStack) lowestUpdated() int {
+	i := len(s.states) - 1
+	for i > 0 && s.states[i].UpdateBelow {
+		i--
+	}
+	return i
+}
+
+//lowestDrawn returns the index of the lowest state that should be drawn
+func (s *GameStateStack) lowestDrawn() int {
+	i := len(s.states) - 1
+	for i > 0 && s.states[i].DrawBelow {
+		i--
+	}
+	return i
+}
+
+//Update the visible portion of the stack from the bottom up.
+//States pushed or popped during the update take effect on the next frame.
+func (s *GameStateStack) Update(g *Game) error {
+	if len(s.states) == 0 {
+		return nil
+	}
+	active := make([]GameState, 0, len(s.states))
+	for i := s.lowestUpdated(); i < len(s.states); i++ {
+		active = append(active, s.states[i].State)
+	}
+	for _, gs := range active {
+		if err := gs.Update(g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//UpdateRealTime calls UpdateRealTime on the states Update would update that implement GameStateRealTimeUpdater
+func (s *GameStateStack) UpdateRealTime(g *Game) error {
+	if len(s.states) == 0 {
+		return nil
+	}
+	active := make([]GameStateRealTimeUpdater, 0, len(s.states))
+	for i := s.lowestUpdated(); i < len(s.states); i++ {
+		if rt, ok := s.states[i].State.(GameStateRealTimeUpdater); ok {
+			active = append(active, rt)
+		}
+	}
+	for _, rt := range active {
+		if err := rt.UpdateRealTime(g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Draw the visible portion of the stack from the bottom up
+func (s *GameStateStack) Draw(g *Game) error {
+	if len(s.states) == 0 {
+		return nil
+	}
+	active := make([]GameState, 0, len(s.states))
+	for i := s.lowestDrawn(); i < len(s.states); i++ {
+		active = append(active, s.states[i].State)
+	}
+	for _, gs := range active {
+		if err := gs.Draw(g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
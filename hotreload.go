@@ -0,0 +1,337 @@
+package tentsuyu
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+var (
+	hotReloadMu sync.Mutex
+	hotReloader *HotReloader
+)
+
+//EnableHotReload starts watching the files loaded from now on through ImageManager.AddImageFromFile, ReadSpriteSheet,
+//CreateTileMapFromFile and AudioPlayer.AddSoundEffectFromFile. The Game checks them every interval (500ms if 0)
+//and reloads those that changed in place, so existing references pick up the new data on the next frame.
+//An image whose size changed is replaced instead, only later ImageManager.ReturnImage lookups see it.
+//It is meant for development, files are polled with os.Stat so it does not work with embedded or wasm assets.
+func EnableHotReload(interval time.Duration) *HotReloader {
+	hotReloadMu.Lock()
+	defer hotReloadMu.Unlock()
+	if hotReloader == nil {
+		hotReloader = NewHotReloader(interval)
+	} else if interval > 0 {
+		hotReloader.Interval = interval
+	}
+	return hotReloader
+}
+
+//DisableHotReload stops watching files
+func DisableHotReload() {
+	hotReloadMu.Lock()
+	hotReloader = nil
+	hotReloadMu.Unlock()
+}
+
+//activeHotReloader returns the HotReloader set by EnableHotReload or nil
+func activeHotReloader() *HotReloader {
+	hotReloadMu.Lock()
+	defer hotReloadMu.Unlock()
+	return hotReloader
+}
+
+//HotReloader polls files for changes and calls their reload functions when the modification time or size changes.
+//OnError is called when a reload fails, the old data is kept until the file changes again.
+//Failed reloads are logged when OnError is nil.
+type HotReloader struct {
+	Interval time.Duration
+	OnReload func(path string)
+	OnError  func(path string, err error)
+	mu       sync.Mutex
+	files    map[string]*watchedFile
+	owners   map[interface{}]string
+	lastPoll time.Time
+}
+
+//watchedFile is a file polled by the HotReloader and the functions reloading the assets read from it
+type watchedFile struct {
+	modTime time.Time
+	size    int64
+	reloads []watchedReload
+}
+
+type watchedReload struct {
+	key    interface{}
+	reload func() error
+}
+
+//NewHotReloader returns a HotReloader polling every interval, 500ms if 0.
+//Use EnableHotReload to have the loaders of tentsuyu register their files with it.
+func NewHotReloader(interval time.Duration) *HotReloader {
+	if interval <= 0 {
+		interval = 500 * time.Millisecond
+	}
+	return &HotReloader{
+		Interval: interval,
+		files:    map[string]*watchedFile{},
+		owners:   map[interface{}]string{},
+	}
+}
+
+//Watch calls reload on the game loop whenever the file at path changes
+func (hr *HotReloader) Watch(path string, reload func() error) {
+	hr.watch(path, nil, reload)
+}
+
+//watch adds the reload function to the file. A key already watched, such as an image name,
+//is moved to the new file so only the file it was last loaded from reloads it.
+func (hr *HotReloader) watch(path string, key interface{}, reload func() error) {
+	path = filepath.Clean(path)
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	if key != nil {
+		hr.unwatchKey(key)
+		hr.owners[key] = path
+	}
+	f, ok := hr.files[path]
+	if !ok {
+		f = &watchedFile{}
+		if info, err := os.Stat(path); err == nil {
+			f.modTime, f.size = info.ModTime(), info.Size()
+		}
+		hr.files[path] = f
+	}
+	f.reloads = append(f.reloads, watchedReload{key: key, reload: reload})
+}
+
+//unwatchKey removes the reload function of the key from the file it was watched at
+func (hr *HotReloader) unwatchKey(key interface{}) {
+	path, ok := hr.owners[key]
+	if !ok {
+		return
+	}
+	delete(hr.owners, key)
+	f := hr.files[path]
+	for i, r := range f.reloads {
+		if r.key == key {
+			f.reloads = append(f.reloads[:i], f.reloads[i+1:]...)
+			break
+		}
+	}
+	if len(f.reloads) == 0 {
+		delete(hr.files, path)
+	}
+}
+
+//Unwatch stops watching the file
+func (hr *HotReloader) Unwatch(path string) {
+	path = filepath.Clean(path)
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	if f, ok := hr.files[path]; ok {
+		for _, r := range f.reloads {
+			if r.key != nil {
+				delete(hr.owners, r.key)
+			}
+		}
+		delete(hr.files, path)
+	}
+}
+
+//Watched returns the paths of the watched files
+func (hr *HotReloader) Watched() []string {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	paths := make([]string, 0, len(hr.files))
+	for path := range hr.files {
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+//Update polls the files once Interval has passed since the last poll. The Game calls it every frame.
+func (hr *HotReloader) Update() {
+	now := time.Now()
+	if now.Sub(hr.lastPoll) < hr.Interval {
+		return
+	}
+	hr.lastPoll = now
+	hr.Poll()
+}
+
+//Poll checks every file now and reloads those that changed, returning their paths
+func (hr *HotReloader) Poll() []string {
+	type change struct {
+		path    string
+		reloads []watchedReload
+	}
+	var changes []change
+	hr.mu.Lock()
+	for path, f := range hr.files {
+		info, err := os.Stat(path)
+		if err != nil {
+			//The file may be missing for a moment while an editor replaces it
+			continue
+		}
+		if info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+			continue
+		}
+		f.modTime, f.size = info.ModTime(), info.Size()
+		changes = append(changes, change{path, append([]watchedReload(nil), f.reloads...)})
+	}
+	hr.mu.Unlock()
+
+	var reloaded []string
+	for _, c := range changes {
+		ok := true
+		for _, r := range c.reloads {
+			if err := r.reload(); err != nil {
+				ok = false
+				hr.fail(c.path, err)
+			}
+		}
+		if ok {
+			reloaded = append(reloaded, c.path)
+			if hr.OnReload != nil {
+				hr.OnReload(c.path)
+			}
+		}
+	}
+	return reloaded
+}
+
+func (hr *HotReloader) fail(path string, err error) {
+	if hr.OnError != nil {
+		hr.OnError(path, err)
+		return
+	}
+	log.Printf("tentsuyu: reloading %s: %v", path, err)
+}
+
+type imageReloadKey struct {
+	im   *ImageManager
+	name string
+}
+
+type soundEffectReloadKey struct {
+	p    *AudioPlayer
+	name string
+}
+
+//watchImage reloads the named image of the ImageManager from path.
+//Images of the same size have their pixels replaced so every *ebiten.Image already handed out is updated.
+//An image of a new size replaces the old one, which is disposed, so only later ReturnImage lookups see it
+//and anything still holding the old image has to look it up again.
+//Nothing is reloaded once the name is removed or given another image.
+func watchImage(im *ImageManager, name, path string) {
+	hr := activeHotReloader()
+	if hr == nil {
+		return
+	}
+	current := im.Images[name]
+	hr.watch(path, imageReloadKey{im, name}, func() error {
+		if im.Images[name] != current {
+			return nil
+		}
+		img, err := decodeImageFile(path)
+		if err != nil {
+			return err
+		}
+		b := img.Bounds()
+		if w, h := current.Size(); w == b.Dx() && h == b.Dy() {
+			rgba := image.NewRGBA(image.Rect(0, 0, w, h))
+			draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+			return current.ReplacePixels(rgba.Pix)
+		}
+		eimg, err := ebiten.NewImageFromImage(img, ebiten.FilterNearest)
+		if err != nil {
+			return err
+		}
+		current.Dispose()
+		current = eimg
+		im.Images[name] = eimg
+		return nil
+	})
+}
+
+func decodeImageFile(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	return img, imageError(path, err)
+}
+
+//watchSpriteSheet replaces the frames and meta of the SpriteSheet when its file changes so Animations using it play the new frames.
+//A sheet with fewer frames is rejected as Animations may still show the frames it dropped.
+func watchSpriteSheet(s *SpriteSheet, path string) {
+	hr := activeHotReloader()
+	if hr == nil {
+		return
+	}
+	hr.watch(path, s, func() error {
+		fresh, err := readSpriteSheet(path)
+		if err != nil {
+			return err
+		}
+		if len(fresh.Frames) < len(s.Frames) {
+			return fmt.Errorf("%s: the sprite sheet has %d frames, fewer than the %d in use", path, len(fresh.Frames), len(s.Frames))
+		}
+		s.Frames, s.Meta = fresh.Frames, fresh.Meta
+		return nil
+	})
+}
+
+//watchTileMap rebuilds the TileMap in place when its file changes.
+//Changes made to the map while the game was running, such as edited tiles, are lost. OnTileChange is kept.
+func watchTileMap(tm *TileMap, path string) {
+	hr := activeHotReloader()
+	if hr == nil {
+		return
+	}
+	hr.watch(path, tm, func() error {
+		m, err := ReadMap(path)
+		if err != nil {
+			return err
+		}
+		fresh := CreateTileMap(m)
+		fresh.OnTileChange = tm.OnTileChange
+		tm.InvalidateCache()
+		*tm = *fresh
+		return nil
+	})
+}
+
+//watchSoundEffect replaces the named sound effect when its file changes, it is played from the next PlaySE on
+func watchSoundEffect(p *AudioPlayer, name, path string) {
+	hr := activeHotReloader()
+	if hr == nil {
+		return
+	}
+	hr.watch(path, soundEffectReloadKey{p, name}, func() error {
+		if _, ok := p.seBytes[name]; !ok {
+			return nil
+		}
+		fb, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		b, err := decodeSoundEffect(fb)
+		if err != nil {
+			return audioError(path, err)
+		}
+		p.seBytes[name] = b
+		return nil
+	})
+}
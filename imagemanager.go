@@ -34,10 +34,11 @@ func (im *ImageManager) AddImageFromFile(name string, path string) error {
 
 	img2, _, err := ebitenutil.NewImageFromFile(path, ebiten.FilterNearest)
 	if err != nil {
-		return err
+		return imageError(path, err)
 	}
 
 	im.Images[name] = img2
+	watchImage(im, name, path)
 	return nil
 }
 
@@ -46,6 +47,15 @@ func (im *ImageManager) AddImage(name string, image *ebiten.Image) {
 	im.Images[name] = image
 }
 
+//RemoveImage removes the image from the ImageManager and disposes it.
+//Frames of an atlas are only released with the page they are cut from.
+func (im *ImageManager) RemoveImage(name string) {
+	if img, ok := im.Images[name]; ok {
+		img.Dispose()
+		delete(im.Images, name)
+	}
+}
+
 //ReturnImage retrieves the specified image name
 func (im *ImageManager) ReturnImage(name string) *ebiten.Image {
 	return im.Images[name]
@@ -56,7 +66,7 @@ func (im *ImageManager) ReturnImage(name string) *ebiten.Image {
 func (im *ImageManager) AddImageFromBytes(name string, b []byte) error {
 	img, _, err := image.Decode(bytes.NewReader(b))
 	if err != nil {
-		return err
+		return imageError("", err)
 	}
 	img2, err := ebiten.NewImageFromImage(img, ebiten.FilterNearest)
 	if err != nil {
@@ -10,6 +10,10 @@ type InputController struct {
 	mouseButtons          map[string]MouseButton
 	Mouse                 *Mouse
 	keyDelay, keyInterval float64
+	source                InputSource
+	inputChars            []rune
+	stepChars             []rune
+	stepping              bool
 }
 
 //NewInputController returns a new InputController
@@ -22,6 +26,7 @@ func NewInputController() *InputController {
 		Mouse:        NewMouse(),
 		keyDelay:     30,
 		keyInterval:  3,
+		source:       EbitenInputSource{},
 	}
 	ic.RegisterMouseButton("LeftMouse", ebiten.MouseButtonLeft)
 	ic.RegisterMouseButton("RightMouse", ebiten.MouseButtonRight)
@@ -32,12 +37,12 @@ func NewInputController() *InputController {
 
 //LeftClick returns the Mouse left click button
 func (ic *InputController) LeftClick() MouseState {
-	return ic.Mouse.buttonMap[ebiten.MouseButtonLeft]
+	return ic.Mouse.Get(ebiten.MouseButtonLeft)
 }
 
 //RightClick returns the Mouse left click button
 func (ic *InputController) RightClick() MouseState {
-	return ic.Mouse.buttonMap[ebiten.MouseButtonRight]
+	return ic.Mouse.Get(ebiten.MouseButtonRight)
 }
 
 //MouseWheelUp returns true if the user is scrolling up
@@ -52,29 +57,81 @@ func (ic *InputController) MouseWheelDown() bool {
 
 //GetMouseCoords returns the ebiten mouse coords
 func (ic *InputController) GetMouseCoords() (float64, float64) {
-	x, y := ebiten.CursorPosition()
+	x, y := ic.source.CursorPosition()
 	return float64(x), float64(y)
 }
 
 //GetGameMouseCoords returns the game coords based on the camera position and zoom
 func (ic *InputController) GetGameMouseCoords(camera *Camera) (x, y float64) {
-	mx, my := ebiten.CursorPosition()
+	mx, my := ic.source.CursorPosition()
 	x, y = (float64(mx)+(camera.GetX()))/(camera.Zoom), (float64(my)+(camera.GetY()))/(camera.Zoom)
 	return x, y
 }
 
 //GetGameMouseCoordsNoZoom is the same as GetGameMouseCoords but ignores the camera's zoom level (useful for drawing the cursor)
 func (ic *InputController) GetGameMouseCoordsNoZoom(camera *Camera) (x, y float64) {
-	mx, my := ebiten.CursorPosition()
+	mx, my := ic.source.CursorPosition()
 	x, y = (float64(mx) + (camera.GetX())), (float64(my) + (camera.GetY()))
 	return x, y
 }
 
 //Update InputController
 func (ic *InputController) Update() {
-	ic.keyManager.update()
+	ic.source.Poll()
+	ic.keyManager.update(ic.source)
 	ic.Mouse.update(ic)
+	ic.inputChars = append(ic.inputChars[:0], ic.source.InputChars()...)
+	ic.stepChars = append(ic.stepChars, ic.inputChars...)
+}
+
+//beginStep switches the InputController to what happened since the last fixed logic step, so presses and
+//characters made on ticks where the Clock ran no steps (pause, hitstop, slow motion) are not lost
+func (ic *InputController) beginStep() {
+	ic.stepping = true
+	ic.keyManager.stepping = true
+	ic.Mouse.stepping = true
+}
+
+//endStep switches back to what happened this tick and marks the input as seen, so a tick running
+//several steps only reports presses and characters to the first
+func (ic *InputController) endStep() {
+	ic.stepping = false
+	ic.keyManager.stepping = false
+	ic.Mouse.stepping = false
+	ic.keyManager.stepped()
+	ic.Mouse.stepped()
+	ic.stepChars = ic.stepChars[:0]
+}
+
+//SetInputSource changes where the InputController reads its input from
+func (ic *InputController) SetInputSource(source InputSource) {
+	ic.source = source
+}
 
+//InputSource returns where the InputController currently reads its input from
+func (ic *InputController) InputSource() InputSource {
+	return ic.source
+}
+
+//InputChars returns the characters typed since the last Update, or since the last fixed logic step while the game steps
+func (ic *InputController) InputChars() []rune {
+	if ic.stepping {
+		return ic.stepChars
+	}
+	return ic.inputChars
+}
+
+//Key returns the state of the key. Keys not used by a registered Button are tracked from the first time they are asked for.
+func (ic *InputController) Key(k ebiten.Key) KeyState {
+	return ic.keyManager.Get(k)
+}
+
+//keyDown reports whether the key is held on the controller, or on the keyboard when there is no controller
+func keyDown(input *InputController, k ebiten.Key) bool {
+	if input == nil {
+		return ebiten.IsKeyPressed(k)
+	}
+	return input.Key(k).Down()
 }
 
 // RegisterButton registers a new button input.
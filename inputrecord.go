@@ -0,0 +1,403 @@
+package tentsuyu
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+//inputRecordingMagic identifies an input recording file
+const inputRecordingMagic = "TSYI"
+
+//inputRecordingVersion is the current version of the input recording format
+const inputRecordingVersion = 1
+
+//recorded mouse buttons in bit order
+var recordedMouseButtons = []ebiten.MouseButton{
+	ebiten.MouseButtonLeft,
+	ebiten.MouseButtonRight,
+	ebiten.MouseButtonMiddle,
+}
+
+//maxInputFrameHint caps the frames preallocated from the count stored in a recording
+const maxInputFrameHint = 1 << 16
+
+const (
+	frameFlagWheel = 1 << iota
+	frameFlagChars
+)
+
+//ErrInvalidInputRecording is returned when reading data that is not an input recording
+var ErrInvalidInputRecording = errors.New("tentsuyu: invalid input recording")
+
+//InputFrame is the complete state of the input during a single tick
+type InputFrame struct {
+	Keys           []ebiten.Key
+	MouseButtons   uint8
+	CursorX        int
+	CursorY        int
+	WheelX, WheelY float64
+	Chars          []rune
+}
+
+//IsKeyPressed returns true if the key was pressed in this frame
+func (f *InputFrame) IsKeyPressed(k ebiten.Key) bool {
+	for _, key := range f.Keys {
+		if key == k {
+			return true
+		}
+	}
+	return false
+}
+
+//IsMouseButtonPressed returns true if the mouse button was pressed in this frame
+func (f *InputFrame) IsMouseButtonPressed(b ebiten.MouseButton) bool {
+	for i, button := range recordedMouseButtons {
+		if button == b {
+			return f.MouseButtons&(1<<uint(i)) != 0
+		}
+	}
+	return false
+}
+
+//InputRecording is a seed and the sequence of InputFrames captured during a session
+type InputRecording struct {
+	Seed   int64
+	Frames []*InputFrame
+}
+
+//Write encodes the recording in its compact gzipped binary form
+func (r *InputRecording) Write(w io.Writer) error {
+	zw := gzip.NewWriter(w)
+	bw := bufio.NewWriter(zw)
+	buf := make([]byte, binary.MaxVarintLen64)
+	putUvarint := func(v uint64) {
+		n := binary.PutUvarint(buf, v)
+		bw.Write(buf[:n])
+	}
+	putVarint := func(v int64) {
+		n := binary.PutVarint(buf, v)
+		bw.Write(buf[:n])
+	}
+	putFloat := func(v float64) {
+		binary.LittleEndian.PutUint64(buf, math.Float64bits(v))
+		bw.Write(buf[:8])
+	}
+
+	bw.WriteString(inputRecordingMagic)
+	putUvarint(inputRecordingVersion)
+	putVarint(r.Seed)
+	putUvarint(uint64(len(r.Frames)))
+	for _, f := range r.Frames {
+		var flags byte
+		if f.WheelX != 0 || f.WheelY != 0 {
+			flags |= frameFlagWheel
+		}
+		if len(f.Chars) > 0 {
+			flags |= frameFlagChars
+		}
+		bw.WriteByte(flags)
+		bw.WriteByte(f.MouseButtons)
+		putVarint(int64(f.CursorX))
+		putVarint(int64(f.CursorY))
+		putUvarint(uint64(len(f.Keys)))
+		for _, k := range f.Keys {
+			putUvarint(uint64(k))
+		}
+		if flags&frameFlagWheel != 0 {
+			putFloat(f.WheelX)
+			putFloat(f.WheelY)
+		}
+		if flags&frameFlagChars != 0 {
+			putUvarint(uint64(len(f.Chars)))
+			for _, c := range f.Chars {
+				putVarint(int64(c))
+			}
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+//SaveFile writes the recording to the file at path
+func (r *InputRecording) SaveFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+//ReadInputRecording decodes a recording written by InputRecording.Write
+func ReadInputRecording(r io.Reader) (*InputRecording, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, ErrInvalidInputRecording
+	}
+	defer zr.Close()
+	br := bufio.NewReader(zr)
+
+	magic := make([]byte, len(inputRecordingMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != inputRecordingMagic {
+		return nil, ErrInvalidInputRecording
+	}
+	version, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	if version != inputRecordingVersion {
+		return nil, ErrInvalidInputRecording
+	}
+	rec := &InputRecording{}
+	if rec.Seed, err = binary.ReadVarint(br); err != nil {
+		return nil, err
+	}
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 8)
+	readFloat := func() (float64, error) {
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return 0, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(buf)), nil
+	}
+	readFrame := func() (*InputFrame, error) {
+		f := &InputFrame{}
+		flags, err := br.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if f.MouseButtons, err = br.ReadByte(); err != nil {
+			return nil, err
+		}
+		x, err := binary.ReadVarint(br)
+		if err != nil {
+			return nil, err
+		}
+		y, err := binary.ReadVarint(br)
+		if err != nil {
+			return nil, err
+		}
+		f.CursorX, f.CursorY = int(x), int(y)
+		keys, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		for k := uint64(0); k < keys; k++ {
+			key, err := binary.ReadUvarint(br)
+			if err != nil {
+				return nil, err
+			}
+			f.Keys = append(f.Keys, ebiten.Key(key))
+		}
+		if flags&frameFlagWheel != 0 {
+			if f.WheelX, err = readFloat(); err != nil {
+				return nil, err
+			}
+			if f.WheelY, err = readFloat(); err != nil {
+				return nil, err
+			}
+		}
+		if flags&frameFlagChars != 0 {
+			chars, err := binary.ReadUvarint(br)
+			if err != nil {
+				return nil, err
+			}
+			for c := uint64(0); c < chars; c++ {
+				r, err := binary.ReadVarint(br)
+				if err != nil {
+					return nil, err
+				}
+				f.Chars = append(f.Chars, rune(r))
+			}
+		}
+		return f, nil
+	}
+	//count comes from the data, it only hints the capacity so a corrupt count cannot allocate more than the frames read
+	hint := count
+	if hint > maxInputFrameHint {
+		hint = maxInputFrameHint
+	}
+	rec.Frames = make([]*InputFrame, 0, hint)
+	for i := uint64(0); i < count; i++ {
+		f, err := readFrame()
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrInvalidInputRecording
+		}
+		if err != nil {
+			return nil, err
+		}
+		rec.Frames = append(rec.Frames, f)
+	}
+	return rec, nil
+}
+
+//LoadInputRecording reads a recording from the file at path
+func LoadInputRecording(path string) (*InputRecording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadInputRecording(f)
+}
+
+//InputRecorder is an InputSource that passes through another InputSource while recording every frame
+type InputRecorder struct {
+	Source    InputSource
+	Recording *InputRecording
+	current   *InputFrame
+}
+
+//NewInputRecorder returns an InputRecorder recording the passed source along with the seed used for the session
+func NewInputRecorder(source InputSource, seed int64) *InputRecorder {
+	return &InputRecorder{
+		Source:    source,
+		Recording: &InputRecording{Seed: seed},
+		current:   &InputFrame{},
+	}
+}
+
+//Poll captures the full state of the source for this frame
+func (r *InputRecorder) Poll() {
+	r.Source.Poll()
+	f := &InputFrame{}
+	for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+		if r.Source.IsKeyPressed(k) {
+			f.Keys = append(f.Keys, k)
+		}
+	}
+	for i, b := range recordedMouseButtons {
+		if r.Source.IsMouseButtonPressed(b) {
+			f.MouseButtons |= 1 << uint(i)
+		}
+	}
+	f.CursorX, f.CursorY = r.Source.CursorPosition()
+	f.WheelX, f.WheelY = r.Source.Wheel()
+	if chars := r.Source.InputChars(); len(chars) > 0 {
+		f.Chars = append([]rune{}, chars...)
+	}
+	r.current = f
+	r.Recording.Frames = append(r.Recording.Frames, f)
+}
+
+//IsKeyPressed returns the recorded state of the key
+func (r *InputRecorder) IsKeyPressed(k ebiten.Key) bool {
+	return r.current.IsKeyPressed(k)
+}
+
+//IsMouseButtonPressed returns the recorded state of the mouse button
+func (r *InputRecorder) IsMouseButtonPressed(b ebiten.MouseButton) bool {
+	return r.current.IsMouseButtonPressed(b)
+}
+
+//CursorPosition returns the recorded cursor position
+func (r *InputRecorder) CursorPosition() (int, int) {
+	return r.current.CursorX, r.current.CursorY
+}
+
+//Wheel returns the recorded wheel offsets
+func (r *InputRecorder) Wheel() (float64, float64) {
+	return r.current.WheelX, r.current.WheelY
+}
+
+//InputChars returns the recorded typed characters
+func (r *InputRecorder) InputChars() []rune {
+	return r.current.Chars
+}
+
+//InputPlayer is an InputSource that plays back an InputRecording one frame per Poll.
+//Once the recording is finished no input is reported.
+type InputPlayer struct {
+	Recording *InputRecording
+	frame     int
+	current   *InputFrame
+}
+
+//NewInputPlayer returns an InputPlayer for the passed recording
+func NewInputPlayer(recording *InputRecording) *InputPlayer {
+	return &InputPlayer{
+		Recording: recording,
+		frame:     -1,
+		current:   &InputFrame{},
+	}
+}
+
+//Poll moves to the next recorded frame
+func (p *InputPlayer) Poll() {
+	p.frame++
+	if p.frame < len(p.Recording.Frames) {
+		p.current = p.Recording.Frames[p.frame]
+	} else {
+		p.current = &InputFrame{
+			CursorX: p.current.CursorX,
+			CursorY: p.current.CursorY,
+		}
+	}
+}
+
+//Frame returns the index of the frame currently being played
+func (p *InputPlayer) Frame() int {
+	return p.frame
+}
+
+//Done returns true once every recorded frame has been played
+func (p *InputPlayer) Done() bool {
+	return p.frame >= len(p.Recording.Frames)-1
+}
+
+//IsKeyPressed returns the recorded state of the key
+func (p *InputPlayer) IsKeyPressed(k ebiten.Key) bool {
+	return p.current.IsKeyPressed(k)
+}
+
+//IsMouseButtonPressed returns the recorded state of the mouse button
+func (p *InputPlayer) IsMouseButtonPressed(b ebiten.MouseButton) bool {
+	return p.current.IsMouseButtonPressed(b)
+}
+
+//CursorPosition returns the recorded cursor position
+func (p *InputPlayer) CursorPosition() (int, int) {
+	return p.current.CursorX, p.current.CursorY
+}
+
+//Wheel returns the recorded wheel offsets
+func (p *InputPlayer) Wheel() (float64, float64) {
+	return p.current.WheelX, p.current.WheelY
+}
+
+//InputChars returns the recorded typed characters
+func (p *InputPlayer) InputChars() []rune {
+	return p.current.Chars
+}
+
+//RecordInput starts recording the game's input, seeding Game.Random with seed so the session can be replayed
+func (g *Game) RecordInput(seed int64) *InputRecorder {
+	g.Random = rand.New(rand.NewSource(seed))
+	recorder := NewInputRecorder(g.Input.InputSource(), seed)
+	g.Input.SetInputSource(recorder)
+	return recorder
+}
+
+//ReplayInput plays back the passed recording through the game's InputController, seeding Game.Random from it
+func (g *Game) ReplayInput(recording *InputRecording) *InputPlayer {
+	g.Random = rand.New(rand.NewSource(recording.Seed))
+	player := NewInputPlayer(recording)
+	g.Input.SetInputSource(player)
+	return player
+}
@@ -0,0 +1,46 @@
+package tentsuyu
+
+import "github.com/hajimehoshi/ebiten"
+
+//InputSource is where the InputController reads the raw state of the keyboard and mouse from.
+//By default this is ebiten itself, but it can be swapped for a recording or scripted input.
+type InputSource interface {
+	//Poll is called once at the start of every InputController update
+	Poll()
+	IsKeyPressed(ebiten.Key) bool
+	IsMouseButtonPressed(ebiten.MouseButton) bool
+	CursorPosition() (int, int)
+	Wheel() (float64, float64)
+	InputChars() []rune
+}
+
+//EbitenInputSource reads input directly from ebiten
+type EbitenInputSource struct{}
+
+//Poll does nothing as ebiten keeps its own input state
+func (EbitenInputSource) Poll() {}
+
+//IsKeyPressed returns true if the key is currently pressed
+func (EbitenInputSource) IsKeyPressed(k ebiten.Key) bool {
+	return ebiten.IsKeyPressed(k)
+}
+
+//IsMouseButtonPressed returns true if the mouse button is currently pressed
+func (EbitenInputSource) IsMouseButtonPressed(b ebiten.MouseButton) bool {
+	return ebiten.IsMouseButtonPressed(b)
+}
+
+//CursorPosition returns the position of the cursor on the screen
+func (EbitenInputSource) CursorPosition() (int, int) {
+	return ebiten.CursorPosition()
+}
+
+//Wheel returns the mouse wheel offsets of this tick
+func (EbitenInputSource) Wheel() (float64, float64) {
+	return ebiten.Wheel()
+}
+
+//InputChars returns the characters typed during this tick
+func (EbitenInputSource) InputChars() []rune {
+	return ebiten.InputChars()
+}
@@ -28,9 +28,10 @@ func NewKeyManager() *KeyManager {
 
 // KeyManager tracks which keys are pressed and released at the current point of time.
 type KeyManager struct {
-	dirtmap map[ebiten.Key]ebiten.Key
-	mapper  map[ebiten.Key]KeyState
-	mutex   sync.RWMutex
+	dirtmap  map[ebiten.Key]ebiten.Key
+	mapper   map[ebiten.Key]KeyState
+	mutex    sync.RWMutex
+	stepping bool
 }
 
 //AddKey adds the ebiten.Key to the list of keys being managed
@@ -56,6 +57,9 @@ func (km *KeyManager) Get(k ebiten.Key) KeyState {
 	ks := KeyState{}
 	if val, ok := km.mapper[k]; ok {
 		ks = val
+		if km.stepping {
+			ks = val.stepView()
+		}
 	} else {
 		km.AddKey(k)
 	}
@@ -63,11 +67,11 @@ func (km *KeyManager) Get(k ebiten.Key) KeyState {
 	return ks
 }
 
-func (km *KeyManager) update() {
+func (km *KeyManager) update(source InputSource) {
 	//km.mutex.Lock()
 
 	for key := range km.mapper {
-		if ebiten.IsKeyPressed(key) {
+		if source.IsKeyPressed(key) {
 			km.Set(key, true)
 		} else {
 			km.Set(key, false)
@@ -77,15 +81,44 @@ func (km *KeyManager) update() {
 	//km.mutex.Unlock()
 }
 
+//stepped marks the current state of every key as seen by a fixed logic step
+func (km *KeyManager) stepped() {
+	km.mutex.Lock()
+	for k, ks := range km.mapper {
+		ks.stepState = ks.currentState
+		ks.pressed = false
+		km.mapper[k] = ks
+	}
+	km.mutex.Unlock()
+}
+
 // KeyState is used for detecting the state of a key press.
+// stepState and pressed hold the state at the last fixed logic step and whether the key went down since,
+// so a press made while the Clock runs no steps is still seen by the next step.
 type KeyState struct {
 	lastState    bool
 	currentState bool
+	stepState    bool
+	pressed      bool
+	tapped       bool
 }
 
 func (key *KeyState) set(state bool) {
 	key.lastState = key.currentState
 	key.currentState = state
+	if state && !key.lastState {
+		key.pressed = true
+	}
+}
+
+//stepView returns the state relative to the last fixed logic step instead of the last tick.
+//A key pressed and released again between two steps is tapped, both just pressed and just released.
+func (key KeyState) stepView() KeyState {
+	return KeyState{
+		lastState:    key.stepState,
+		currentState: key.currentState,
+		tapped:       key.pressed && !key.stepState && !key.currentState,
+	}
 }
 
 // State returns the raw state of a key.
@@ -97,7 +130,7 @@ func (key *KeyState) State() int {
 		return KeyStateJustUp
 
 	}
-	if key.currentState {
+	if key.currentState || key.tapped {
 		return KeyStateJustDown
 	}
 	return KeyStateUp
@@ -106,17 +139,17 @@ func (key *KeyState) State() int {
 
 // JustPressed returns whether a key was just pressed
 func (key KeyState) JustPressed() bool {
-	return (!key.lastState && key.currentState)
+	return (!key.lastState && key.currentState) || key.tapped
 }
 
 // JustReleased returns whether a key was just released
 func (key KeyState) JustReleased() bool {
-	return (key.lastState && !key.currentState)
+	return (key.lastState && !key.currentState) || key.tapped
 }
 
 // Up returns wheter a key is not being pressed
 func (key KeyState) Up() bool {
-	return (!key.lastState && !key.currentState)
+	return (!key.lastState && !key.currentState) && !key.tapped
 }
 
 // Down returns wether a key is being pressed
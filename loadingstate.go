@@ -0,0 +1,68 @@
+package tentsuyu
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+//LoadingState is a GameState that runs an AssetLoader and shows its progress.
+//Once every asset is loaded it is replaced by Next, or OnComplete is called instead when it is set.
+//If any asset failed to load, Update returns the error unless IgnoreErrors is set.
+type LoadingState struct {
+	*BaseGameState
+	Loader       *AssetLoader
+	Next         GameState
+	IgnoreErrors bool
+	//OnComplete replaces switching to Next when it is set
+	OnComplete func(g *Game, loader *AssetLoader) error
+	//DrawProgress replaces the default progress bar when it is set
+	DrawProgress func(g *Game, screen *ebiten.Image, loader *AssetLoader) error
+	BarColor     color.Color
+	finished     bool
+}
+
+//NewLoadingState returns a LoadingState running the loader before switching to next
+func NewLoadingState(loader *AssetLoader, next GameState) *LoadingState {
+	return &LoadingState{
+		BaseGameState: NewBaseGameState(),
+		Loader:        loader,
+		Next:          next,
+		BarColor:      color.White,
+	}
+}
+
+//Update adds the finished assets to the Game and moves on once they are all loaded
+func (ls *LoadingState) Update(g *Game) error {
+	if ls.finished || !ls.Loader.Update(g) {
+		return nil
+	}
+	ls.finished = true
+	if err := ls.Loader.Err(); err != nil && !ls.IgnoreErrors {
+		return err
+	}
+	if ls.OnComplete != nil {
+		return ls.OnComplete(g, ls.Loader)
+	}
+	if ls.Next != nil {
+		return g.ReplaceGameState(ls.Next)
+	}
+	return nil
+}
+
+//Draw shows the progress of the loader, as a bar and percentage unless DrawProgress is set
+func (ls *LoadingState) Draw(g *Game) error {
+	screen := g.Screen
+	if ls.DrawProgress != nil {
+		return ls.DrawProgress(g, screen, ls.Loader)
+	}
+	w, h := screen.Size()
+	barW, barH := float64(w)*0.6, 8.0
+	x, y := (float64(w)-barW)/2, float64(h)/2
+	ebitenutil.DrawRect(screen, x-1, y-1, barW+2, barH+2, color.Gray{Y: 0x40})
+	ebitenutil.DrawRect(screen, x, y, barW*ls.Loader.Progress(), barH, ls.BarColor)
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Now Loading... %d%%", ls.Loader.Percent()), int(x), int(y)-20)
+	return nil
+}
@@ -113,7 +113,7 @@ func (m *Menu) Draw(screen *ebiten.Image, camera *Camera) {
 		scalex := float64(m.maxWidth)/100 + .85
 		scaley := float64(m.maxHeight)/100 + .2
 		op := &ebiten.DrawImageOptions{}
-		op.ImageParts = m.backgroundImgParts
+		op.ImageParts = ImagePartsFor(m.backgroundImage, m.backgroundImgParts)
 		//op.GeoM.Translate(-w, -h)
 		op.GeoM.Scale(float64(scalex), float64(scaley))
 		//op.GeoM.Translate(w*float64(scalex), h*float64(scaley))
@@ -24,6 +24,8 @@ type Mouse struct {
 	mutex            sync.RWMutex
 	mouseWheelMoving bool
 	scroll           int
+	stepScroll       int
+	stepping         bool
 }
 
 //Set tells the mouse to press the selected mouse button
@@ -41,6 +43,9 @@ func (m *Mouse) Set(key ebiten.MouseButton, state bool) {
 func (m *Mouse) Get(k ebiten.MouseButton) MouseState {
 	m.mutex.RLock()
 	ms := m.buttonMap[k]
+	if m.stepping {
+		ms = ms.stepView()
+	}
 	m.mutex.RUnlock()
 
 	return ms
@@ -48,7 +53,7 @@ func (m *Mouse) Get(k ebiten.MouseButton) MouseState {
 
 //IsScrollDown returns true if the user is scrolling down on the mouse wheel
 func (m *Mouse) IsScrollDown() bool {
-	if m.scroll < 0 {
+	if m.scrolled() < 0 {
 		return true
 	}
 	return false
@@ -56,16 +61,36 @@ func (m *Mouse) IsScrollDown() bool {
 
 //IsScrollUp returns true if the user is scrolling up on the mouse wheel
 func (m *Mouse) IsScrollUp() bool {
-	if m.scroll > 0 {
+	if m.scrolled() > 0 {
 		return true
 	}
 	return false
 }
 
+//scrolled returns the direction of the wheel this tick, or since the last fixed logic step while stepping
+func (m *Mouse) scrolled() int {
+	if m.stepping {
+		return m.stepScroll
+	}
+	return m.scroll
+}
+
+//stepped marks the current state of every button and the wheel as seen by a fixed logic step
+func (m *Mouse) stepped() {
+	m.mutex.Lock()
+	for k, ms := range m.buttonMap {
+		ms.stepState = ms.currentState
+		ms.pressed = false
+		m.buttonMap[k] = ms
+	}
+	m.stepScroll = 0
+	m.mutex.Unlock()
+}
+
 func (m *Mouse) update(input *InputController) {
 	m.X, m.Y = input.GetMouseCoords() //m.GetGameMouseCoordsNoZoom()
 	m.mouseWheelMoving = false
-	wX, wY := ebiten.Wheel()
+	wX, wY := input.source.Wheel()
 	if wX != 0 || wY != 0 {
 		//moving the mouse wheel
 		m.mouseWheelMoving = true
@@ -89,10 +114,13 @@ func (m *Mouse) update(input *InputController) {
 	} else {
 		m.scroll = 0
 	}
+	if m.scroll != 0 {
+		m.stepScroll = m.scroll
+	}
 	m.wX = wX
 	m.wY = wY
 	for key := range m.buttonMap {
-		if ebiten.IsMouseButtonPressed(key) {
+		if input.source.IsMouseButtonPressed(key) {
 			m.Set(key, true)
 		} else {
 			m.Set(key, false)
@@ -103,8 +131,7 @@ func (m *Mouse) update(input *InputController) {
 
 //GetGameMouseCoordsNoZoom is the same as GetGameMouseCoords but ignores the camera's zoom level (useful for drawing the cursor)
 func (m *Mouse) GetGameMouseCoordsNoZoom(camera *Camera) (x, y float64) {
-	mx, my := ebiten.CursorPosition()
-	x, y = (float64(mx) + (camera.GetX())), (float64(my) + (camera.GetY()))
+	x, y = (m.X + (camera.GetX())), (m.Y + (camera.GetY()))
 	return x, y
 }
 
@@ -125,14 +152,30 @@ func (m *Mouse) AddKey(k ebiten.MouseButton) {
 }
 
 // MouseState is used for detecting the state of a key press.
+// Like KeyState it remembers the state at the last fixed logic step.
 type MouseState struct {
 	lastState    bool
 	currentState bool
+	stepState    bool
+	pressed      bool
+	tapped       bool
 }
 
 func (key *MouseState) set(state bool) {
 	key.lastState = key.currentState
 	key.currentState = state
+	if state && !key.lastState {
+		key.pressed = true
+	}
+}
+
+//stepView returns the state relative to the last fixed logic step instead of the last tick
+func (key MouseState) stepView() MouseState {
+	return MouseState{
+		lastState:    key.stepState,
+		currentState: key.currentState,
+		tapped:       key.pressed && !key.stepState && !key.currentState,
+	}
 }
 
 // State returns the raw state of a key.
@@ -144,7 +187,7 @@ func (key *MouseState) State() int {
 		return MouseStateJustUp
 
 	}
-	if key.currentState {
+	if key.currentState || key.tapped {
 		return MouseStateJustDown
 	}
 	return MouseStateUp
@@ -153,17 +196,17 @@ func (key *MouseState) State() int {
 
 // JustPressed returns whether a key was just pressed
 func (key MouseState) JustPressed() bool {
-	return (!key.lastState && key.currentState)
+	return (!key.lastState && key.currentState) || key.tapped
 }
 
 // JustReleased returns whether a key was just released
 func (key MouseState) JustReleased() bool {
-	return (key.lastState && !key.currentState)
+	return (key.lastState && !key.currentState) || key.tapped
 }
 
 // Up returns wheter a key is not being pressed
 func (key MouseState) Up() bool {
-	return (!key.lastState && !key.currentState)
+	return (!key.lastState && !key.currentState) && !key.tapped
 }
 
 // Down returns wether a key is being pressed
@@ -0,0 +1,188 @@
+package tentsuyu
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+//ParallaxBackground is an image drawn behind or in front of the world that scrolls at its own speed.
+//A parallax factor of 1 moves with the world and values below it make the image look further away.
+//As with a TileLayer a factor of 0 is treated as 1, use FixedX and FixedY to keep the image fixed on the screen.
+//The image can repeat to fill the screen horizontally and vertically.
+//An Opacity of 0 is treated as fully opaque, use Hidden to hide the background.
+type ParallaxBackground struct {
+	ImageName            string
+	X, Y                 float64
+	ParallaxX, ParallaxY float64
+	FixedX, FixedY       bool
+	OriginX, OriginY     float64
+	RepeatX, RepeatY     bool
+	Opacity              float64
+	Tint                 color.Color
+	Hidden               bool
+}
+
+//NewParallaxBackground returns a ParallaxBackground of the image scrolling at the given parallax factors,
+//a factor of 0 sets FixedX or FixedY
+func NewParallaxBackground(imageName string, parallaxX, parallaxY float64) *ParallaxBackground {
+	return &ParallaxBackground{
+		ImageName: imageName,
+		ParallaxX: parallaxX,
+		ParallaxY: parallaxY,
+		FixedX:    parallaxX == 0,
+		FixedY:    parallaxY == 0,
+		Opacity:   1,
+	}
+}
+
+//Draw the background as seen through the camera
+func (p *ParallaxBackground) Draw(screen *ebiten.Image, imageManager *ImageManager, camera *Camera) error {
+	if p.Hidden {
+		return nil
+	}
+	img := imageManager.ReturnImage(p.ImageName)
+	if img == nil {
+		return nil
+	}
+	w, h := img.Size()
+	px, py := parallaxFactors(p.ParallaxX, p.ParallaxY, p.FixedX, p.FixedY)
+	ox, oy := ParallaxOffset(camera, px, py, p.OriginX, p.OriginY)
+	op := &ebiten.DrawImageOptions{}
+	camera.DrawCameraTransform(op)
+	drawRepeated(screen, img, p.X+ox, p.Y+oy, w, h, p.RepeatX, p.RepeatY, layerColorM(p.Opacity, p.Tint), op.GeoM, camera)
+	return nil
+}
+
+//ParallaxOffset returns how far a layer with the parallax factors is moved from its world position
+//when seen through the camera. The layer is in its world position when the center of the camera is at the origin.
+func ParallaxOffset(camera *Camera, parallaxX, parallaxY, originX, originY float64) (float64, float64) {
+	x0, y0, x1, y1 := camera.VisibleBounds()
+	centerX, centerY := (x0+x1)/2, (y0+y1)/2
+	return (centerX - originX) * (1 - parallaxX), (centerY - originY) * (1 - parallaxY)
+}
+
+//drawRepeated draws the image at x, y in the world, repeating it across the area visible through the camera
+func drawRepeated(screen, img *ebiten.Image, x, y float64, w, h int, repeatX, repeatY bool, colorM *ebiten.ColorM, world ebiten.GeoM, camera *Camera) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+	fw, fh := float64(w), float64(h)
+	x0, y0, x1, y1 := camera.VisibleBounds()
+	startX, endX := x, x+fw
+	if repeatX {
+		startX = x + math.Floor((x0-x)/fw)*fw
+		endX = x1
+	}
+	startY, endY := y, y+fh
+	if repeatY {
+		startY = y + math.Floor((y0-y)/fh)*fh
+		endY = y1
+	}
+	for dy := startY; dy < endY; dy += fh {
+		for dx := startX; dx < endX; dx += fw {
+			op := &ebiten.DrawImageOptions{}
+			op.ImageParts = ImagePartsFor(img, &BasicImageParts{Width: w, Height: h})
+			op.GeoM.Translate(dx, dy)
+			op.GeoM.Concat(world)
+			if colorM != nil {
+				op.ColorM = *colorM
+			}
+			screen.DrawImage(img, op)
+		}
+	}
+}
+
+//layerColorM returns the color matrix applying the opacity and tint, nil if the layer is drawn as it is
+func layerColorM(opacity float64, tint color.Color) *ebiten.ColorM {
+	if (opacity <= 0 || opacity >= 1) && tint == nil {
+		return nil
+	}
+	colorM := &ebiten.ColorM{}
+	if tint != nil {
+		r, g, b, a := tint.RGBA()
+		colorM.Scale(float64(r)/0xffff, float64(g)/0xffff, float64(b)/0xffff, float64(a)/0xffff)
+	}
+	if opacity > 0 && opacity < 1 {
+		colorM.Scale(1, 1, 1, opacity)
+	}
+	return colorM
+}
+
+//multiplyColor combines the tints of a group and one of its layers
+func multiplyColor(a, b color.Color) color.Color {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	return color.RGBA64{
+		R: uint16(ar * br / 0xffff),
+		G: uint16(ag * bg / 0xffff),
+		B: uint16(ab * bb / 0xffff),
+		A: uint16(aa * ba / 0xffff),
+	}
+}
+
+//Parallax returns the parallax factors of the Tiled layer, 1 when they are not set
+func (l *Layer) Parallax() (float64, float64) {
+	x, y := 1.0, 1.0
+	if l.ParallaxX != nil {
+		x = *l.ParallaxX
+	}
+	if l.ParallaxY != nil {
+		y = *l.ParallaxY
+	}
+	return x, y
+}
+
+//SetParallax sets the parallax factors of the Tiled layer, leaving out factors of 1 as Tiled does
+func (l *Layer) SetParallax(x, y float64) {
+	l.ParallaxX, l.ParallaxY = nil, nil
+	if x != 1 {
+		l.ParallaxX = &x
+	}
+	if y != 1 {
+		l.ParallaxY = &y
+	}
+}
+
+//parallax returns the parallax factors the layer is drawn with
+func (tl *TileLayer) parallax() (float64, float64) {
+	return parallaxFactors(tl.ParallaxX, tl.ParallaxY, tl.FixedX, tl.FixedY)
+}
+
+//parallaxFactors returns the parallax factors to draw with, 0 when fixed on the screen and 1 for an unset factor
+func parallaxFactors(x, y float64, fixedX, fixedY bool) (float64, float64) {
+	if x == 0 {
+		x = 1
+	}
+	if y == 0 {
+		y = 1
+	}
+	if fixedX {
+		x = 0
+	}
+	if fixedY {
+		y = 0
+	}
+	return x, y
+}
+
+//parallaxOffset returns how far the layer is moved by its parallax factors when seen through the camera
+func (tl *TileLayer) parallaxOffset(camera *Camera) (float64, float64) {
+	x, y := tl.parallax()
+	if x == 1 && y == 1 {
+		return 0, 0
+	}
+	return ParallaxOffset(camera, x, y, tl.parallaxOriginX, tl.parallaxOriginY)
+}
+
+//colorM returns the color matrix for the opacity and tint of the layer
+func (tl *TileLayer) colorM() *ebiten.ColorM {
+	return layerColorM(tl.Opacity, tl.Tint)
+}
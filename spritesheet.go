@@ -1,19 +1,27 @@
 package tentsuyu
 
 import (
-	"encoding/json"
 	"io/ioutil"
-	"log"
-	"os"
 	"strconv"
 )
 
 //SpriteSheet holds all frames of a spritesheet from a json
 type SpriteSheet struct {
-	Frames                                []Frame `json:"frames"`
+	Frames                                []Frame         `json:"frames"`
+	Meta                                  SpriteSheetMeta `json:"meta"`
 	framePaddingWidth, framePaddingHeight int
 }
 
+//SpriteSheetMeta holds the image the frames are cut from and, for atlases split over several pages,
+//the JSON files of the other pages. Sprite sheets exported by Aseprite also have frame tags and slices.
+type SpriteSheetMeta struct {
+	Image             string         `json:"image"`
+	Size              map[string]int `json:"size"`
+	RelatedMultiPacks []string       `json:"related_multi_packs"`
+	FrameTags         []FrameTag     `json:"frameTags"`
+	Slices            []Slice        `json:"slices"`
+}
+
 //Frame represents a single frame of a spritesheet
 type Frame struct {
 	Filename         string             `json:"filename"`
@@ -23,26 +31,38 @@ type Frame struct {
 	SpriteSourceSize map[string]int     `json:"spriteSourceSize"`
 	SourceSize       map[string]int     `json:"sourceSize"`
 	Pivot            map[string]float64 `json:"pivot"`
+	Duration         int                `json:"duration"` // milliseconds, set by Aseprite
 }
 
 //ReadSpriteSheet reads a json file and returns a SpriteSheet struct
-func ReadSpriteSheet(filename string) *SpriteSheet {
-	raw, err := ioutil.ReadFile(filename)
+func ReadSpriteSheet(filename string) (*SpriteSheet, error) {
+	m, err := readSpriteSheet(filename)
 	if err != nil {
-		log.Println(err.Error())
-		os.Exit(1)
+		return nil, err
 	}
+	watchSpriteSheet(m, filename)
+	return m, nil
+}
 
+func readSpriteSheet(filename string) (*SpriteSheet, error) {
+	raw, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
 	m := &SpriteSheet{}
-	json.Unmarshal(raw, m)
-	return m
+	if err := unmarshalJSON(filename, raw, m); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
 
 //ReadSpriteSheetJSON from a byte slice
-func ReadSpriteSheetJSON(jsonByte []byte) *SpriteSheet {
+func ReadSpriteSheetJSON(jsonByte []byte) (*SpriteSheet, error) {
 	m := &SpriteSheet{}
-	json.Unmarshal(jsonByte, m)
-	return m
+	if err := unmarshalJSON("", jsonByte, m); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
 
 //NewSpriteSheet returns a SpriteSheet struct with just a basic
@@ -2,7 +2,6 @@ package tentsuyu
 
 import (
 	"image/color"
-	"log"
 	"math"
 
 	"github.com/hajimehoshi/ebiten"
@@ -13,12 +12,8 @@ var Pixel *ebiten.Image
 
 func init() {
 	//Create Pixel
-	var err error
-	//Simple white 1x1 pixel image for manipulation
-	Pixel, err := ebiten.NewImage(1, 1, ebiten.FilterNearest)
-	if err != nil {
-		log.Fatal(err)
-	}
+	//Simple white 1x1 pixel image for manipulation, NewImage has not returned an error since ebiten 1.5
+	Pixel, _ = ebiten.NewImage(1, 1, ebiten.FilterNearest)
 	Pixel.Fill(color.RGBA{R: 255, G: 255, B: 255, A: 255})
 }
 
@@ -46,7 +41,8 @@ func Collision(obj1 *BasicObject, obj2 *BasicObject) bool {
 
 //Point represents a point in 2D space
 type Point struct {
-	X, Y float64
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
 }
 
 // DrawLine draws a line segment on the given destination dst.
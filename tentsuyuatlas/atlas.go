@@ -0,0 +1,254 @@
+package tentsuyuatlas
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	//Sprites may be png or jpg files
+	_ "image/jpeg"
+)
+
+//Sprite is a named image to pack
+type Sprite struct {
+	Name  string
+	Image image.Image
+}
+
+//Options control the size of the atlas pages
+type Options struct {
+	//MaxWidth and MaxHeight limit the size of each page, 2048 if not set
+	MaxWidth, MaxHeight int
+	//Padding is the number of empty pixels kept between sprites so filtering does not bleed between them
+	Padding int
+	//PowerOfTwo rounds the size of each page up to a power of two
+	PowerOfTwo bool
+}
+
+//Region is where a sprite was placed on its page
+type Region struct {
+	Name string
+	Rect image.Rectangle
+}
+
+//Page is a single atlas image and the sprites placed on it
+type Page struct {
+	Image   *image.RGBA
+	Regions []Region
+}
+
+//Pack places the sprites on as few pages as it can, largest sprites first
+func Pack(sprites []Sprite, opts Options) ([]*Page, error) {
+	if opts.MaxWidth <= 0 {
+		opts.MaxWidth = 2048
+	}
+	if opts.MaxHeight <= 0 {
+		opts.MaxHeight = 2048
+	}
+	sorted := make([]Sprite, len(sprites))
+	copy(sorted, sprites)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Image.Bounds(), sorted[j].Image.Bounds()
+		ma, mb := maxInt(a.Dx(), a.Dy()), maxInt(b.Dx(), b.Dy())
+		if ma != mb {
+			return ma > mb
+		}
+		return a.Dx()*a.Dy() > b.Dx()*b.Dy()
+	})
+
+	//The bins are one padding larger so sprites can touch the right and bottom edges of the page
+	names := map[string]bool{}
+	bins := []*MaxRects{}
+	regions := [][]Region{}
+	for _, s := range sorted {
+		if names[s.Name] {
+			return nil, fmt.Errorf("tentsuyuatlas: sprite %q added twice", s.Name)
+		}
+		names[s.Name] = true
+		b := s.Image.Bounds()
+		w, h := b.Dx()+opts.Padding, b.Dy()+opts.Padding
+		if b.Dx() > opts.MaxWidth || b.Dy() > opts.MaxHeight {
+			return nil, fmt.Errorf("tentsuyuatlas: sprite %q is %dx%d, larger than the %dx%d page", s.Name, b.Dx(), b.Dy(), opts.MaxWidth, opts.MaxHeight)
+		}
+		placed := false
+		for i, bin := range bins {
+			if r, ok := bin.Insert(w, h); ok {
+				regions[i] = append(regions[i], Region{Name: s.Name, Rect: image.Rect(r.Min.X, r.Min.Y, r.Min.X+b.Dx(), r.Min.Y+b.Dy())})
+				placed = true
+				break
+			}
+		}
+		if placed {
+			continue
+		}
+		bin := NewMaxRects(opts.MaxWidth+opts.Padding, opts.MaxHeight+opts.Padding)
+		r, _ := bin.Insert(w, h)
+		bins = append(bins, bin)
+		regions = append(regions, []Region{{Name: s.Name, Rect: image.Rect(r.Min.X, r.Min.Y, r.Min.X+b.Dx(), r.Min.Y+b.Dy())}})
+	}
+
+	images := map[string]image.Image{}
+	for _, s := range sprites {
+		images[s.Name] = s.Image
+	}
+	pages := make([]*Page, len(regions))
+	for i, rs := range regions {
+		width, height := 0, 0
+		for _, r := range rs {
+			width, height = maxInt(width, r.Rect.Max.X), maxInt(height, r.Rect.Max.Y)
+		}
+		if opts.PowerOfTwo {
+			width, height = powerOfTwo(width), powerOfTwo(height)
+		}
+		page := &Page{Image: image.NewRGBA(image.Rect(0, 0, width, height)), Regions: rs}
+		for _, r := range rs {
+			img := images[r.Name]
+			draw.Draw(page.Image, r.Rect, img, img.Bounds().Min, draw.Src)
+		}
+		pages[i] = page
+	}
+	return pages, nil
+}
+
+//sheet is the TexturePacker JSON array layout read by tentsuyu.SpriteSheet
+type sheet struct {
+	Frames []sheetFrame `json:"frames"`
+	Meta   sheetMeta    `json:"meta"`
+}
+
+type sheetFrame struct {
+	Filename         string         `json:"filename"`
+	Frame            map[string]int `json:"frame"`
+	Rotated          bool           `json:"rotated"`
+	Trimmed          bool           `json:"trimmed"`
+	SpriteSourceSize map[string]int `json:"spriteSourceSize"`
+	SourceSize       map[string]int `json:"sourceSize"`
+}
+
+type sheetMeta struct {
+	App               string         `json:"app"`
+	Image             string         `json:"image"`
+	Format            string         `json:"format"`
+	Size              map[string]int `json:"size"`
+	Scale             string         `json:"scale"`
+	RelatedMultiPacks []string       `json:"related_multi_packs,omitempty"`
+}
+
+//JSON returns the sprite sheet of the page, naming imageName as its image.
+//related lists the JSON files of the other pages of the same atlas.
+func (p *Page) JSON(imageName string, related []string) ([]byte, error) {
+	s := sheet{
+		Frames: make([]sheetFrame, len(p.Regions)),
+		Meta: sheetMeta{
+			App:               "tentsuyuatlas",
+			Image:             imageName,
+			Format:            "RGBA8888",
+			Size:              map[string]int{"w": p.Image.Rect.Dx(), "h": p.Image.Rect.Dy()},
+			Scale:             "1",
+			RelatedMultiPacks: related,
+		},
+	}
+	for i, r := range p.Regions {
+		w, h := r.Rect.Dx(), r.Rect.Dy()
+		s.Frames[i] = sheetFrame{
+			Filename:         r.Name,
+			Frame:            map[string]int{"x": r.Rect.Min.X, "y": r.Rect.Min.Y, "w": w, "h": h},
+			SpriteSourceSize: map[string]int{"x": 0, "y": 0, "w": w, "h": h},
+			SourceSize:       map[string]int{"w": w, "h": h},
+		}
+	}
+	return json.MarshalIndent(s, "", " ")
+}
+
+//Write saves every page as prefix-N.png with its sprite sheet in prefix-N.json
+func Write(pages []*Page, prefix string) error {
+	if dir := filepath.Dir(prefix); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	base := filepath.Base(prefix)
+	for i, p := range pages {
+		related := []string{}
+		for j := range pages {
+			if j != i {
+				related = append(related, fmt.Sprintf("%s-%d.json", base, j))
+			}
+		}
+		imageName := fmt.Sprintf("%s-%d.png", base, i)
+		data, err := p.JSON(imageName, related)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(fmt.Sprintf("%s-%d.json", prefix, i), data, 0644); err != nil {
+			return err
+		}
+		f, err := os.Create(fmt.Sprintf("%s-%d.png", prefix, i))
+		if err != nil {
+			return err
+		}
+		if err := png.Encode(f, p.Image); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//LoadDir reads every png and jpg under the directory. Sprites are named by their path
+//relative to the directory without the extension, using forward slashes, such as "player/run1".
+func LoadDir(dir string) ([]Sprite, error) {
+	sprites := []Sprite{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext != ".png" && ext != ".jpg" && ext != ".jpeg" {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		img, _, err := image.Decode(f)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		sprites = append(sprites, Sprite{
+			Name:  filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel))),
+			Image: img,
+		})
+		return nil
+	})
+	return sprites, err
+}
+
+func powerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p *= 2
+	}
+	return p
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
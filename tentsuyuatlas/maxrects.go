@@ -0,0 +1,108 @@
+//Package tentsuyuatlas packs many images into a few large atlas pages using the max-rects algorithm
+//and writes them with the TexturePacker style JSON read by tentsuyu.SpriteSheet.
+//It only uses the standard image packages so it can run at build time without a window.
+package tentsuyuatlas
+
+import (
+	"image"
+	"math"
+)
+
+//MaxRects places rectangles into a fixed size bin, keeping a list of the largest free rectangles left.
+//Each rectangle goes where it leaves the shortest side of a free rectangle unused (best short side fit).
+type MaxRects struct {
+	Width, Height int
+	free          []image.Rectangle
+	used          []image.Rectangle
+}
+
+//NewMaxRects returns an empty bin of the given size
+func NewMaxRects(width, height int) *MaxRects {
+	return &MaxRects{
+		Width:  width,
+		Height: height,
+		free:   []image.Rectangle{image.Rect(0, 0, width, height)},
+	}
+}
+
+//Insert finds room for a rectangle of the size and marks it as used, false if it does not fit
+func (m *MaxRects) Insert(width, height int) (image.Rectangle, bool) {
+	if width <= 0 || height <= 0 {
+		return image.Rectangle{}, false
+	}
+	best := -1
+	bestShort, bestLong := math.MaxInt32, math.MaxInt32
+	for i, f := range m.free {
+		leftW, leftH := f.Dx()-width, f.Dy()-height
+		if leftW < 0 || leftH < 0 {
+			continue
+		}
+		short, long := leftW, leftH
+		if short > long {
+			short, long = long, short
+		}
+		if short < bestShort || (short == bestShort && long < bestLong) {
+			best, bestShort, bestLong = i, short, long
+		}
+	}
+	if best < 0 {
+		return image.Rectangle{}, false
+	}
+	r := image.Rectangle{Min: m.free[best].Min, Max: m.free[best].Min.Add(image.Pt(width, height))}
+	m.place(r)
+	return r, true
+}
+
+//Used returns the rectangles placed so far
+func (m *MaxRects) Used() []image.Rectangle {
+	return m.used
+}
+
+//Occupancy returns the fraction of the bin covered by placed rectangles
+func (m *MaxRects) Occupancy() float64 {
+	area := 0
+	for _, r := range m.used {
+		area += r.Dx() * r.Dy()
+	}
+	return float64(area) / float64(m.Width*m.Height)
+}
+
+//place splits every free rectangle overlapping r into the parts around it and removes
+//the free rectangles that end up inside another one
+func (m *MaxRects) place(r image.Rectangle) {
+	free := make([]image.Rectangle, 0, len(m.free)+4)
+	for _, f := range m.free {
+		if !f.Overlaps(r) {
+			free = append(free, f)
+			continue
+		}
+		if r.Min.X > f.Min.X {
+			free = append(free, image.Rect(f.Min.X, f.Min.Y, r.Min.X, f.Max.Y))
+		}
+		if r.Max.X < f.Max.X {
+			free = append(free, image.Rect(r.Max.X, f.Min.Y, f.Max.X, f.Max.Y))
+		}
+		if r.Min.Y > f.Min.Y {
+			free = append(free, image.Rect(f.Min.X, f.Min.Y, f.Max.X, r.Min.Y))
+		}
+		if r.Max.Y < f.Max.Y {
+			free = append(free, image.Rect(f.Min.X, r.Max.Y, f.Max.X, f.Max.Y))
+		}
+	}
+
+	m.free = m.free[:0]
+	for i, a := range free {
+		contained := false
+		for j, b := range free {
+			//Of two equal rectangles only the first is kept
+			if i != j && a.In(b) && (a != b || j < i) {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			m.free = append(m.free, a)
+		}
+	}
+	m.used = append(m.used, r)
+}
@@ -0,0 +1,253 @@
+//Package tentsuyuparticle emits, simulates and draws particles for effects such as explosions, smoke and sparks.
+//Emitters are described by an EmitterConfig that can be read from JSON so effects are tuned without recompiling,
+//keep their particles in a fixed pool and draw them through a tentsuyu.Camera.
+package tentsuyuparticle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"math/rand"
+
+	"github.com/atolVerderben/tentsuyu"
+)
+
+//Blend modes of an EmitterConfig
+const (
+	BlendNormal   = "normal"
+	BlendAdditive = "additive"
+)
+
+//defaultMaxParticles is the size of the pool of an emitter whose config does not set one
+const defaultMaxParticles = 256
+
+//EmitterConfig describes an effect. Distances are in pixels, times in seconds and angles in degrees,
+//with 0 pointing right and 90 down the screen.
+type EmitterConfig struct {
+	Name string `json:"name"`
+	//Image is the name of the texture in the ImageManager, particles are single white pixels without one
+	Image string `json:"image"`
+	//SpriteSheet is the name of a sprite sheet cutting Image into frames, Frames are the frames used or all of them if empty
+	SpriteSheet string `json:"spriteSheet"`
+	Frames      []int  `json:"frames"`
+	//AnimateFrames plays the Frames over the lifetime of each particle instead of giving each a random frame
+	AnimateFrames bool `json:"animateFrames"`
+	//MaxParticles is the size of the pool, no more particles are emitted while it is full
+	MaxParticles int `json:"maxParticles"`
+	//Rate is the number of particles emitted per second and Burst the number emitted at once on Start
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+	//Duration is how long the emitter emits at Rate after Start, forever if 0
+	Duration float64 `json:"duration"`
+	//SpawnWidth and SpawnHeight are the size of the area around the emitter particles are placed in
+	SpawnWidth  float64        `json:"spawnWidth"`
+	SpawnHeight float64        `json:"spawnHeight"`
+	Lifetime    Range          `json:"lifetime"`
+	Speed       Range          `json:"speed"`
+	Angle       float64        `json:"angle"`
+	Spread      float64        `json:"spread"`
+	Rotation    Range          `json:"rotation"`
+	Spin        Range          `json:"spin"`
+	Gravity     tentsuyu.Point `json:"gravity"`
+	//Damping is the fraction of their velocity particles lose every second
+	Damping float64 `json:"damping"`
+	Scale   Range   `json:"scale"`
+	//ScaleOverLife and ColorOverLife are keyed by the age of a particle from 0 at its birth to 1 at its death
+	ScaleOverLife []ScaleKey `json:"scaleOverLife"`
+	ColorOverLife []ColorKey `json:"colorOverLife"`
+	Blend         string     `json:"blend"`
+}
+
+//Range is a value picked at random between Min and Max. In JSON it is either an object or a single number for both.
+type Range struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+//UnmarshalJSON reads the range as {"min": 1, "max": 2} or as a single number
+func (r *Range) UnmarshalJSON(b []byte) error {
+	if b = bytes.TrimSpace(b); len(b) > 0 && b[0] != '{' {
+		var v float64
+		if err := json.Unmarshal(b, &v); err != nil {
+			return err
+		}
+		r.Min, r.Max = v, v
+		return nil
+	}
+	type plain Range
+	return json.Unmarshal(b, (*plain)(r))
+}
+
+//Random returns a value between Min and Max
+func (r Range) Random(rnd *rand.Rand) float64 {
+	if r.Max <= r.Min {
+		return r.Min
+	}
+	return r.Min + rnd.Float64()*(r.Max-r.Min)
+}
+
+//ScaleKey is the scale of a particle at Time through its life. Ease is the name of a tentsuyu easing,
+//such as "EaseOutQuad", used towards the next key, linear if empty.
+type ScaleKey struct {
+	Time  float64 `json:"time"`
+	Scale float64 `json:"scale"`
+	Ease  string  `json:"ease"`
+}
+
+//ColorKey is the color of a particle at Time through its life. Colors are written as Tiled writes them, #AARRGGBB or #RRGGBB.
+//Ease is the name of a tentsuyu easing used towards the next key, linear if empty.
+type ColorKey struct {
+	Time  float64    `json:"time"`
+	Color color.RGBA `json:"-"`
+	Ease  string     `json:"ease"`
+}
+
+//UnmarshalJSON reads the color of the key from a color string
+func (k *ColorKey) UnmarshalJSON(b []byte) error {
+	var aux struct {
+		Time  float64 `json:"time"`
+		Color string  `json:"color"`
+		Ease  string  `json:"ease"`
+	}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	c, ok := tentsuyu.ParseTiledColor(aux.Color)
+	if !ok {
+		return fmt.Errorf("tentsuyuparticle: invalid color %q", aux.Color)
+	}
+	k.Time, k.Color, k.Ease = aux.Time, c, aux.Ease
+	return nil
+}
+
+//MarshalJSON writes the color of the key as #AARRGGBB
+func (k ColorKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Time  float64 `json:"time"`
+		Color string  `json:"color"`
+		Ease  string  `json:"ease,omitempty"`
+	}{k.Time, fmt.Sprintf("#%02x%02x%02x%02x", k.Color.A, k.Color.R, k.Color.G, k.Color.B), k.Ease})
+}
+
+//ReadEmitterConfig reads an EmitterConfig from a JSON file
+func ReadEmitterConfig(filename string) (*EmitterConfig, error) {
+	raw, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return readEmitterConfig(filename, raw)
+}
+
+//ReadEmitterConfigJSON reads an EmitterConfig from a byte slice
+func ReadEmitterConfigJSON(b []byte) (*EmitterConfig, error) {
+	return readEmitterConfig("", b)
+}
+
+func readEmitterConfig(path string, raw []byte) (*EmitterConfig, error) {
+	cfg := &EmitterConfig{}
+	if err := json.Unmarshal(raw, cfg); err != nil {
+		return nil, &tentsuyu.MalformedJSONError{Path: path, Err: err}
+	}
+	if err := cfg.Validate(); err != nil {
+		if path != "" {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		return nil, err
+	}
+	return cfg, nil
+}
+
+//Validate returns an error for settings the emitter cannot use, such as an unknown blend mode or easing
+func (cfg *EmitterConfig) Validate() error {
+	switch cfg.Blend {
+	case "", BlendNormal, BlendAdditive:
+	default:
+		return fmt.Errorf("tentsuyuparticle: emitter %q has unknown blend %q, expected %s or %s", cfg.Name, cfg.Blend, BlendNormal, BlendAdditive)
+	}
+	if len(cfg.Frames) > 0 && cfg.SpriteSheet == "" {
+		return fmt.Errorf("tentsuyuparticle: emitter %q has frames but no sprite sheet", cfg.Name)
+	}
+	for _, k := range cfg.ScaleOverLife {
+		if err := checkEase(k.Ease); err != nil {
+			return err
+		}
+	}
+	for _, k := range cfg.ColorOverLife {
+		if err := checkEase(k.Ease); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkEase(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, ok := tentsuyu.EaseByName(name); !ok {
+		return fmt.Errorf("tentsuyuparticle: unknown easing %q", name)
+	}
+	return nil
+}
+
+//ease returns the easing with the name, Linear if empty
+func ease(name string) tentsuyu.EaseFunc {
+	if e, ok := tentsuyu.EaseByName(name); ok {
+		return e
+	}
+	return tentsuyu.Linear
+}
+
+//scaleAt returns the scale of the keys at the time through a particle's life, 1 without keys
+func scaleAt(keys []ScaleKey, t float64) float64 {
+	if len(keys) == 0 {
+		return 1
+	}
+	if t <= keys[0].Time {
+		return keys[0].Scale
+	}
+	for i := 1; i < len(keys); i++ {
+		if t < keys[i].Time {
+			a, b := keys[i-1], keys[i]
+			p := ease(a.Ease)((t - a.Time) / (b.Time - a.Time))
+			return a.Scale + (b.Scale-a.Scale)*p
+		}
+	}
+	return keys[len(keys)-1].Scale
+}
+
+//colorAt returns the color of the keys at the time through a particle's life, white without keys
+func colorAt(keys []ColorKey, t float64) color.RGBA {
+	if len(keys) == 0 {
+		return color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	}
+	if t <= keys[0].Time {
+		return keys[0].Color
+	}
+	for i := 1; i < len(keys); i++ {
+		if t < keys[i].Time {
+			a, b := keys[i-1], keys[i]
+			p := ease(a.Ease)((t - a.Time) / (b.Time - a.Time))
+			return color.RGBA{
+				R: lerpChannel(a.Color.R, b.Color.R, p),
+				G: lerpChannel(a.Color.G, b.Color.G, p),
+				B: lerpChannel(a.Color.B, b.Color.B, p),
+				A: lerpChannel(a.Color.A, b.Color.A, p),
+			}
+		}
+	}
+	return keys[len(keys)-1].Color
+}
+
+func lerpChannel(from, to uint8, p float64) uint8 {
+	v := float64(from) + (float64(to)-float64(from))*p + 0.5
+	switch {
+	case v < 0:
+		return 0
+	case v > 255:
+		return 255
+	}
+	return uint8(v)
+}
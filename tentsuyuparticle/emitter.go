@@ -0,0 +1,306 @@
+package tentsuyuparticle
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/atolVerderben/tentsuyu"
+	"github.com/hajimehoshi/ebiten"
+)
+
+//durationEpsilon absorbs the rounding errors of adding up fixed steps such as 1/60s
+const durationEpsilon = 1e-9
+
+//Particle is a single particle of an Emitter. Particles are stored by value in the pool of their emitter
+//and reused once they die, so pointers to them are only valid until the next Update.
+type Particle struct {
+	X, Y       float64
+	VX, VY     float64
+	Rotation   float64
+	Spin       float64
+	Scale      float64
+	Age        float64
+	Lifetime   float64
+	Frame      int
+	Color      color.RGBA
+	baseScale  float64
+	frameIndex int
+}
+
+//Life returns how far the particle is through its lifetime, from 0 at its birth to 1 at its death
+func (p *Particle) Life() float64 {
+	if p.Lifetime <= 0 {
+		return 1
+	}
+	return p.Age / p.Lifetime
+}
+
+//Emitter emits particles as described by its Config and moves them each Update.
+//The particles live in a pool the size of MaxParticles allocated once, so emitting does not create garbage.
+type Emitter struct {
+	Config   *EmitterConfig
+	X, Y     float64
+	Random   *rand.Rand
+	texture  *ebiten.Image
+	frames   []*ebiten.Image
+	pool     []Particle
+	emitting bool
+	burst    int
+	elapsed  float64
+	emitted  int
+	op       ebiten.DrawImageOptions
+}
+
+//NewEmitter returns an Emitter at x, y that starts emitting on its first Update.
+//The particles are randomised from rnd, pass Game.Random so replays emit the same particles,
+//or nil for a source seeded from the current time.
+//Particles are white pixels until a texture is set with SetImage, SetSpriteSheet or LoadTexture.
+func NewEmitter(cfg *EmitterConfig, x, y float64, rnd *rand.Rand) *Emitter {
+	max := cfg.MaxParticles
+	if max <= 0 {
+		max = defaultMaxParticles
+	}
+	if rnd == nil {
+		rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	e := &Emitter{
+		Config:  cfg,
+		X:       x,
+		Y:       y,
+		Random:  rnd,
+		texture: tentsuyu.Pixel,
+		pool:    make([]Particle, 0, max),
+	}
+	e.Start()
+	return e
+}
+
+//SetImage draws every particle with the image
+func (e *Emitter) SetImage(img *ebiten.Image) {
+	e.texture = img
+	e.frames = nil
+}
+
+//SetSpriteSheet draws the particles with the Frames of the Config cut from the image by the sprite sheet,
+//or every frame of the sheet if the Config has none. The frames are cut once, set the sprite sheet again after it changes.
+func (e *Emitter) SetSpriteSheet(img *ebiten.Image, sheet *tentsuyu.SpriteSheet) error {
+	frames := e.Config.Frames
+	if len(frames) == 0 {
+		for i := range sheet.Frames {
+			frames = append(frames, i)
+		}
+	}
+	e.frames = make([]*ebiten.Image, 0, len(frames))
+	for _, f := range frames {
+		if f < 0 || f >= len(sheet.Frames) {
+			return fmt.Errorf("tentsuyuparticle: emitter %q uses frame %d but the sprite sheet has %d", e.Config.Name, f, len(sheet.Frames))
+		}
+		e.frames = append(e.frames, tentsuyu.BasicImagePartsFromSpriteSheet(sheet, f).SubImage(img))
+	}
+	e.texture = img
+	return nil
+}
+
+//LoadTexture sets the texture named by the Image and SpriteSheet of the Config, such as from
+//Game.ImageManager and Game.SpriteSheets
+func (e *Emitter) LoadTexture(im *tentsuyu.ImageManager, sheets map[string]*tentsuyu.SpriteSheet) error {
+	if e.Config.Image == "" {
+		return nil
+	}
+	img := im.ReturnImage(e.Config.Image)
+	if img == nil {
+		return fmt.Errorf("tentsuyuparticle: emitter %q uses image %q which is not loaded", e.Config.Name, e.Config.Image)
+	}
+	if e.Config.SpriteSheet == "" {
+		e.SetImage(img)
+		return nil
+	}
+	sheet, ok := sheets[e.Config.SpriteSheet]
+	if !ok {
+		return fmt.Errorf("tentsuyuparticle: emitter %q uses sprite sheet %q which is not loaded", e.Config.Name, e.Config.SpriteSheet)
+	}
+	return e.SetSpriteSheet(img, sheet)
+}
+
+//SetPosition moves the emitter, particles already emitted are not moved
+func (e *Emitter) SetPosition(x, y float64) {
+	e.X, e.Y = x, y
+}
+
+//Start emits the Burst of the Config on the next Update and emits at its Rate for its Duration
+func (e *Emitter) Start() {
+	e.emitting = true
+	e.elapsed = 0
+	e.emitted = 0
+	e.burst += e.Config.Burst
+}
+
+//Stop stops emitting, the particles already emitted live out their lifetime
+func (e *Emitter) Stop() {
+	e.emitting = false
+	e.burst = 0
+}
+
+//Burst emits n particles at once on the next Update
+func (e *Emitter) Burst(n int) {
+	e.burst += n
+}
+
+//Emitting returns true while the emitter emits at its Rate
+func (e *Emitter) Emitting() bool {
+	return e.emitting
+}
+
+//Done returns true once the emitter has stopped emitting and all of its particles have died
+func (e *Emitter) Done() bool {
+	return !e.emitting && e.burst == 0 && len(e.pool) == 0
+}
+
+//Len returns the number of live particles
+func (e *Emitter) Len() int {
+	return len(e.pool)
+}
+
+//Particles returns the live particles
+func (e *Emitter) Particles() []Particle {
+	return e.pool
+}
+
+//Clear kills every particle
+func (e *Emitter) Clear() {
+	e.pool = e.pool[:0]
+}
+
+//Update moves the particles on by dt seconds, kills those that outlived their lifetime and emits new ones.
+//Pass Clock.SubsystemDelta(tentsuyu.ClockParticles) to have the particles follow the game's time scale.
+func (e *Emitter) Update(dt float64) {
+	cfg := e.Config
+	damping := 1.0
+	if cfg.Damping > 0 {
+		damping = math.Max(0, 1-cfg.Damping*dt)
+	}
+	for i := 0; i < len(e.pool); {
+		p := &e.pool[i]
+		p.Age += dt
+		if p.Age >= p.Lifetime {
+			//Swap the dead particle with the last so the live ones stay at the front of the pool
+			last := len(e.pool) - 1
+			e.pool[i] = e.pool[last]
+			e.pool = e.pool[:last]
+			continue
+		}
+		p.VX = (p.VX + cfg.Gravity.X*dt) * damping
+		p.VY = (p.VY + cfg.Gravity.Y*dt) * damping
+		p.X += p.VX * dt
+		p.Y += p.VY * dt
+		p.Rotation += p.Spin * dt
+		e.age(p)
+		i++
+	}
+
+	for ; e.burst > 0; e.burst-- {
+		e.emit()
+	}
+	if !e.emitting {
+		return
+	}
+	e.elapsed += dt
+	if cfg.Duration > 0 && e.elapsed >= cfg.Duration-durationEpsilon {
+		e.elapsed = cfg.Duration
+		e.emitting = false
+	}
+	if cfg.Rate <= 0 {
+		e.emitting = false
+		return
+	}
+	//Count from the start so rounding errors in dt do not add up to a missing particle
+	for due := int(e.elapsed*cfg.Rate + durationEpsilon); e.emitted < due; e.emitted++ {
+		e.emit()
+	}
+}
+
+//emit places a new particle if the pool is not full
+func (e *Emitter) emit() {
+	if len(e.pool) == cap(e.pool) {
+		return
+	}
+	cfg := e.Config
+	e.pool = e.pool[:len(e.pool)+1]
+	p := &e.pool[len(e.pool)-1]
+
+	angle := (cfg.Angle + (e.Random.Float64()-0.5)*cfg.Spread) * math.Pi / 180
+	speed := cfg.Speed.Random(e.Random)
+	*p = Particle{
+		X:         e.X + (e.Random.Float64()-0.5)*cfg.SpawnWidth,
+		Y:         e.Y + (e.Random.Float64()-0.5)*cfg.SpawnHeight,
+		VX:        math.Cos(angle) * speed,
+		VY:        math.Sin(angle) * speed,
+		Rotation:  cfg.Rotation.Random(e.Random) * math.Pi / 180,
+		Spin:      cfg.Spin.Random(e.Random) * math.Pi / 180,
+		Lifetime:  cfg.Lifetime.Random(e.Random),
+		baseScale: 1,
+	}
+	if p.Lifetime <= 0 {
+		p.Lifetime = 1
+	}
+	if cfg.Scale.Max > 0 {
+		p.baseScale = cfg.Scale.Random(e.Random)
+	}
+	if len(e.frames) > 0 && !cfg.AnimateFrames {
+		p.frameIndex = e.Random.Intn(len(e.frames))
+	}
+	e.age(p)
+}
+
+//age sets the scale, color and frame of the particle for how far it is through its life
+func (e *Emitter) age(p *Particle) {
+	t := p.Life()
+	p.Scale = p.baseScale * scaleAt(e.Config.ScaleOverLife, t)
+	p.Color = colorAt(e.Config.ColorOverLife, t)
+	if e.Config.AnimateFrames && len(e.frames) > 0 {
+		p.frameIndex = int(t * float64(len(e.frames)))
+		if p.frameIndex >= len(e.frames) {
+			p.frameIndex = len(e.frames) - 1
+		}
+	}
+	if p.frameIndex < len(e.Config.Frames) {
+		p.Frame = e.Config.Frames[p.frameIndex]
+	} else {
+		p.Frame = p.frameIndex
+	}
+}
+
+//Draw draws the particles centred on their positions through the camera, or in screen space if the camera is nil
+func (e *Emitter) Draw(screen *ebiten.Image, camera *tentsuyu.Camera) error {
+	op := &e.op
+	if e.Config.Blend == BlendAdditive {
+		op.CompositeMode = ebiten.CompositeModeLighter
+	} else {
+		op.CompositeMode = ebiten.CompositeModeSourceOver
+	}
+	for i := range e.pool {
+		p := &e.pool[i]
+		img := e.texture
+		if len(e.frames) > 0 {
+			img = e.frames[p.frameIndex]
+		}
+		w, h := img.Size()
+		op.GeoM.Reset()
+		op.GeoM.Translate(-float64(w)/2, -float64(h)/2)
+		op.GeoM.Scale(p.Scale, p.Scale)
+		op.GeoM.Rotate(p.Rotation)
+		op.GeoM.Translate(p.X, p.Y)
+		if camera != nil {
+			camera.ApplyCameraTransform(op, true)
+		}
+		op.ColorM.Reset()
+		op.ColorM.Scale(float64(p.Color.R)/0xff, float64(p.Color.G)/0xff, float64(p.Color.B)/0xff, float64(p.Color.A)/0xff)
+		if err := screen.DrawImage(img, op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
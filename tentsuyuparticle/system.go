@@ -0,0 +1,116 @@
+package tentsuyuparticle
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/atolVerderben/tentsuyu"
+	"github.com/hajimehoshi/ebiten"
+)
+
+//System updates and draws a group of emitters and spawns new ones from named configs.
+//Emitters are dropped once they are Done, so one-shot effects such as explosions clean up after themselves.
+//Random is passed to every spawned emitter, set it to Game.Random so replays of an InputRecorder emit the same
+//particles. Emitters spawned without it are seeded from the current time.
+type System struct {
+	Emitters     []*Emitter
+	Configs      map[string]*EmitterConfig
+	ImageManager *tentsuyu.ImageManager
+	SpriteSheets map[string]*tentsuyu.SpriteSheet
+	Random       *rand.Rand
+}
+
+//NewSystem returns a System loading the textures of its emitters from the ImageManager and sprite sheets,
+//usually Game.ImageManager and Game.SpriteSheets
+func NewSystem(im *tentsuyu.ImageManager, sheets map[string]*tentsuyu.SpriteSheet) *System {
+	return &System{
+		Configs:      map[string]*EmitterConfig{},
+		ImageManager: im,
+		SpriteSheets: sheets,
+	}
+}
+
+//AddConfig adds the config under its Name for Spawn
+func (s *System) AddConfig(cfg *EmitterConfig) {
+	s.Configs[cfg.Name] = cfg
+}
+
+//LoadConfig reads a JSON emitter config from the file and adds it under its Name
+func (s *System) LoadConfig(filename string) (*EmitterConfig, error) {
+	cfg, err := ReadEmitterConfig(filename)
+	if err != nil {
+		return nil, err
+	}
+	s.AddConfig(cfg)
+	return cfg, nil
+}
+
+//Spawn adds a new emitter from the named config at x, y
+func (s *System) Spawn(name string, x, y float64) (*Emitter, error) {
+	cfg, ok := s.Configs[name]
+	if !ok {
+		return nil, fmt.Errorf("tentsuyuparticle: no emitter config %q", name)
+	}
+	e := NewEmitter(cfg, x, y, s.Random)
+	if s.ImageManager != nil {
+		if err := e.LoadTexture(s.ImageManager, s.SpriteSheets); err != nil {
+			return nil, err
+		}
+	}
+	s.Add(e)
+	return e, nil
+}
+
+//Add adds the emitter to the System
+func (s *System) Add(e *Emitter) {
+	s.Emitters = append(s.Emitters, e)
+}
+
+//Remove removes the emitter and its particles from the System
+func (s *System) Remove(e *Emitter) {
+	for i, em := range s.Emitters {
+		if em == e {
+			s.Emitters = append(s.Emitters[:i], s.Emitters[i+1:]...)
+			return
+		}
+	}
+}
+
+//Clear removes every emitter
+func (s *System) Clear() {
+	s.Emitters = nil
+}
+
+//Len returns the number of live particles of every emitter
+func (s *System) Len() int {
+	n := 0
+	for _, e := range s.Emitters {
+		n += e.Len()
+	}
+	return n
+}
+
+//Update updates every emitter by dt seconds and drops those that are done
+func (s *System) Update(dt float64) {
+	live := s.Emitters[:0]
+	for _, e := range s.Emitters {
+		e.Update(dt)
+		if !e.Done() {
+			live = append(live, e)
+		}
+	}
+	for i := len(live); i < len(s.Emitters); i++ {
+		s.Emitters[i] = nil
+	}
+	s.Emitters = live
+}
+
+//Draw draws every emitter through the camera, or in screen space if the camera is nil
+func (s *System) Draw(screen *ebiten.Image, camera *tentsuyu.Camera) error {
+	for _, e := range s.Emitters {
+		if err := e.Draw(screen, camera); err != nil {
+			return err
+		}
+	}
+	return nil
+}
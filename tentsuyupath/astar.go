@@ -0,0 +1,86 @@
+package tentsuyupath
+
+import (
+	"container/heap"
+	"math"
+)
+
+//queueItem is a cell waiting in a priorityQueue
+type queueItem struct {
+	index    int
+	priority float64
+}
+
+//priorityQueue is a min-heap of cells ordered by priority
+type priorityQueue []queueItem
+
+func (q priorityQueue) Len() int            { return len(q) }
+func (q priorityQueue) Less(i, j int) bool  { return q[i].priority < q[j].priority }
+func (q priorityQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *priorityQueue) Push(x interface{}) { *q = append(*q, x.(queueItem)) }
+func (q *priorityQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+//FindPath returns the cheapest path from start to goal including both cells,
+//or false if the goal cannot be reached
+func (g *Grid) FindPath(start, goal Cell) ([]Cell, bool) {
+	si, gi := g.index(start.Col, start.Row), g.index(goal.Col, goal.Row)
+	if si < 0 || gi < 0 || !g.Walkable(goal.Col, goal.Row) {
+		return nil, false
+	}
+	if si == gi {
+		return []Cell{start}, true
+	}
+
+	cost := make([]float64, len(g.costs))
+	parent := make([]int, len(g.costs))
+	for i := range cost {
+		cost[i] = math.Inf(1)
+		parent[i] = -1
+	}
+	closed := make([]bool, len(g.costs))
+	cost[si] = 0
+	open := &priorityQueue{{index: si, priority: g.heuristic(start, goal)}}
+
+	for open.Len() > 0 {
+		current := heap.Pop(open).(queueItem)
+		if closed[current.index] {
+			continue
+		}
+		if current.index == gi {
+			return g.buildPath(parent, gi), true
+		}
+		closed[current.index] = true
+		c := g.cell(current.index)
+		for _, n := range g.moves() {
+			col, row := c.Col+n.dx, c.Row+n.dy
+			ni := g.index(col, row)
+			if ni < 0 || closed[ni] || !g.Walkable(col, row) || !g.canMove(c.Col, c.Row, n) {
+				continue
+			}
+			next := cost[current.index] + g.costs[ni]*n.length
+			if next < cost[ni] {
+				cost[ni] = next
+				parent[ni] = current.index
+				heap.Push(open, queueItem{index: ni, priority: next + g.heuristic(Cell{col, row}, goal)})
+			}
+		}
+	}
+	return nil, false
+}
+
+//buildPath follows the parents back from the end cell
+func (g *Grid) buildPath(parent []int, end int) []Cell {
+	path := []Cell{}
+	for i := end; i >= 0; i = parent[i] {
+		path = append(path, g.cell(i))
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
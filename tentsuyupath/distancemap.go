@@ -0,0 +1,235 @@
+package tentsuyupath
+
+import (
+	"container/heap"
+	"math"
+)
+
+//DistanceMap is a Dijkstra map holding the cost from every cell to the nearest goal.
+//Any number of agents can read it to head for the goals. Changes to the costs of the grid
+//are applied the next time the map is read, recalculating only the cells they affect.
+type DistanceMap struct {
+	grid    *Grid
+	goals   []Cell
+	isGoal  []bool
+	dist    []float64
+	parent  []int
+	pending []int
+}
+
+//NewDistanceMap calculates the distance from every cell of the grid to the nearest of the goals
+func (g *Grid) NewDistanceMap(goals ...Cell) *DistanceMap {
+	d := &DistanceMap{
+		grid:   g,
+		dist:   make([]float64, len(g.costs)),
+		parent: make([]int, len(g.costs)),
+		isGoal: make([]bool, len(g.costs)),
+	}
+	g.listeners = append(g.listeners, d)
+	d.SetGoals(goals...)
+	return d
+}
+
+//Close stops the map following changes to the grid. It should be called once the map is no longer used.
+func (d *DistanceMap) Close() {
+	ls := d.grid.listeners
+	for i, l := range ls {
+		if l == d {
+			d.grid.listeners = append(ls[:i], ls[i+1:]...)
+			return
+		}
+	}
+}
+
+//Goals returns the goals of the map
+func (d *DistanceMap) Goals() []Cell {
+	return d.goals
+}
+
+//SetGoals replaces the goals and calculates the whole map again
+func (d *DistanceMap) SetGoals(goals ...Cell) {
+	d.goals = goals
+	d.pending = nil
+	q := &priorityQueue{}
+	for i := range d.dist {
+		d.dist[i] = math.Inf(1)
+		d.parent[i] = -1
+		d.isGoal[i] = false
+	}
+	for _, c := range goals {
+		i := d.grid.index(c.Col, c.Row)
+		if i < 0 {
+			continue
+		}
+		d.isGoal[i] = true
+		if d.grid.Walkable(c.Col, c.Row) {
+			d.dist[i] = 0
+			heap.Push(q, queueItem{index: i})
+		}
+	}
+	d.run(q)
+}
+
+//run spreads the distances outwards from the cells in the queue
+func (d *DistanceMap) run(q *priorityQueue) {
+	g := d.grid
+	for q.Len() > 0 {
+		current := heap.Pop(q).(queueItem)
+		if current.priority > d.dist[current.index] {
+			continue
+		}
+		c := g.cell(current.index)
+		//Agents move from the neighbour into this cell so they pay its cost
+		enter := g.costs[current.index]
+		for _, n := range g.moves() {
+			col, row := c.Col+n.dx, c.Row+n.dy
+			ni := g.index(col, row)
+			if ni < 0 || d.isGoal[ni] || !g.Walkable(col, row) || !g.canMove(col, row, neighbour{-n.dx, -n.dy, n.length}) {
+				continue
+			}
+			next := d.dist[current.index] + enter*n.length
+			if next < d.dist[ni] {
+				d.dist[ni] = next
+				d.parent[ni] = current.index
+				heap.Push(q, queueItem{index: ni, priority: next})
+			}
+		}
+	}
+}
+
+//update applies the changes made to the grid since the map was last read.
+//Every cell whose path to a goal went through a changed cell, or cut the corner of one,
+//is cleared and filled in again from the cells around it.
+func (d *DistanceMap) update() {
+	if len(d.pending) == 0 {
+		return
+	}
+	g := d.grid
+	cleared := make(map[int]bool)
+	stack := []int{}
+	for _, i := range d.pending {
+		stack = append(stack, i)
+		c := g.cell(i)
+		for _, n := range neighbours {
+			if ni := g.index(c.Col+n.dx, c.Row+n.dy); ni >= 0 {
+				if p := d.parent[ni]; p >= 0 && (n.dx != 0 && n.dy != 0 || p == i || d.isDiagonal(ni, p)) {
+					stack = append(stack, ni)
+				}
+			}
+		}
+	}
+	d.pending = nil
+
+	//Clear the cells and everything that reached a goal through them
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if cleared[i] {
+			continue
+		}
+		cleared[i] = true
+		c := g.cell(i)
+		for _, n := range g.moves() {
+			if ni := g.index(c.Col+n.dx, c.Row+n.dy); ni >= 0 && d.parent[ni] == i && !cleared[ni] {
+				stack = append(stack, ni)
+			}
+		}
+	}
+
+	q := &priorityQueue{}
+	for i := range cleared {
+		d.dist[i] = math.Inf(1)
+		d.parent[i] = -1
+		if d.isGoal[i] {
+			c := g.cell(i)
+			if g.Walkable(c.Col, c.Row) {
+				d.dist[i] = 0
+				heap.Push(q, queueItem{index: i})
+			}
+		}
+	}
+	//Start again from the cells bordering the cleared area
+	for i := range cleared {
+		c := g.cell(i)
+		for _, n := range g.moves() {
+			ni := g.index(c.Col+n.dx, c.Row+n.dy)
+			if ni >= 0 && !cleared[ni] && !math.IsInf(d.dist[ni], 1) {
+				heap.Push(q, queueItem{index: ni, priority: d.dist[ni]})
+			}
+		}
+	}
+	d.run(q)
+}
+
+//isDiagonal returns true if the move between the two cells is diagonal
+func (d *DistanceMap) isDiagonal(a, b int) bool {
+	ca, cb := d.grid.cell(a), d.grid.cell(b)
+	return ca.Col != cb.Col && ca.Row != cb.Row
+}
+
+//Distance returns the cost of the cheapest path from the cell to a goal, +Inf if no goal can be reached
+func (d *DistanceMap) Distance(col, row int) float64 {
+	d.update()
+	i := d.grid.index(col, row)
+	if i < 0 {
+		return math.Inf(1)
+	}
+	return d.dist[i]
+}
+
+//Reachable returns true if a goal can be reached from the cell
+func (d *DistanceMap) Reachable(col, row int) bool {
+	return !math.IsInf(d.Distance(col, row), 1)
+}
+
+//Next returns the next cell on the way to the nearest goal, false at a goal or if no goal can be reached
+func (d *DistanceMap) Next(col, row int) (Cell, bool) {
+	d.update()
+	i := d.grid.index(col, row)
+	if i < 0 || d.parent[i] < 0 {
+		return Cell{}, false
+	}
+	return d.grid.cell(d.parent[i]), true
+}
+
+//PathFrom returns the path from the cell to the nearest goal including both, false if no goal can be reached
+func (d *DistanceMap) PathFrom(col, row int) ([]Cell, bool) {
+	if !d.Reachable(col, row) {
+		return nil, false
+	}
+	path := []Cell{{col, row}}
+	for c, ok := d.Next(col, row); ok; c, ok = d.Next(c.Col, c.Row) {
+		path = append(path, c)
+	}
+	return path, true
+}
+
+//FlowField gives every cell the direction to move in to reach the nearest goal.
+//It is built on a DistanceMap and follows changes to the grid the same way.
+type FlowField struct {
+	*DistanceMap
+}
+
+//NewFlowField creates a FlowField leading to the nearest of the goals
+func (g *Grid) NewFlowField(goals ...Cell) *FlowField {
+	return &FlowField{DistanceMap: g.NewDistanceMap(goals...)}
+}
+
+//Direction returns the step in columns and rows to take from the cell, false at a goal or if no goal can be reached
+func (f *FlowField) Direction(col, row int) (dx, dy int, ok bool) {
+	next, ok := f.Next(col, row)
+	if !ok {
+		return 0, 0, false
+	}
+	return next.Col - col, next.Row - row, true
+}
+
+//Vector returns the direction to move in from the cell as a unit vector, 0,0 at a goal or if no goal can be reached
+func (f *FlowField) Vector(col, row int) (float64, float64) {
+	dx, dy, ok := f.Direction(col, row)
+	if !ok {
+		return 0, 0
+	}
+	l := math.Hypot(float64(dx), float64(dy))
+	return float64(dx) / l, float64(dy) / l
+}
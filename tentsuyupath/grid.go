@@ -0,0 +1,274 @@
+//Package tentsuyupath finds paths over a grid of cells, usually built from a tentsuyu.TileMap.
+//It provides A* paths for single agents and Dijkstra distance maps and flow fields for many agents
+//heading to the same goals, all of which follow changes made to the grid.
+package tentsuyupath
+
+import (
+	"math"
+
+	"github.com/atolVerderben/tentsuyu"
+)
+
+//Movement is the set of neighbours an agent can move to from a cell
+type Movement int
+
+//Movements
+const (
+	FourWay Movement = iota
+	EightWay
+)
+
+//DiagonalRule decides when a diagonal move is allowed past the two cells beside it
+type DiagonalRule int
+
+//Diagonal rules
+const (
+	//DiagonalNoCornerCutting only allows a diagonal move when both cells beside it are walkable
+	DiagonalNoCornerCutting DiagonalRule = iota
+	//DiagonalOneCorner allows a diagonal move when at least one cell beside it is walkable
+	DiagonalOneCorner
+	//DiagonalAlways allows every diagonal move between walkable cells
+	DiagonalAlways
+)
+
+//Blocked is the cost of a cell that cannot be entered
+var Blocked = math.Inf(1)
+
+//Cell is a column and row of the grid
+type Cell struct {
+	Col, Row int
+}
+
+//CostFunc returns the cost of entering the cell. Return Blocked, or any negative number, for walls.
+type CostFunc func(col, row int) float64
+
+//Grid is the navigation grid paths are found on. Each cell has a cost of entering it,
+//1 for normal ground, higher for slow ground and Blocked for walls.
+type Grid struct {
+	Width, Height    int
+	OriginX, OriginY int
+	Movement         Movement
+	Diagonal         DiagonalRule
+	costs            []float64
+	minCost          float64
+	listeners        []*DistanceMap
+}
+
+//NewGrid creates a grid where every cell costs 1
+func NewGrid(width, height int) *Grid {
+	g := &Grid{
+		Width:   width,
+		Height:  height,
+		costs:   make([]float64, width*height),
+		minCost: 1,
+	}
+	for i := range g.costs {
+		g.costs[i] = 1
+	}
+	return g
+}
+
+//NewGridFromFunc creates a grid using the cost function for every cell
+func NewGridFromFunc(width, height int, cost CostFunc) *Grid {
+	g := NewGrid(width, height)
+	for row := 0; row < height; row++ {
+		for col := 0; col < width; col++ {
+			g.setCost(col, row, cost(col, row))
+		}
+	}
+	return g
+}
+
+//NewGridFromTileMap creates a grid covering every tile layer of the TileMap.
+//Solid tiles block the cell, except one-way platforms and slopes which can be walked over.
+//A tile with a "walkable" property of false also blocks the cell and a "cost" property sets its cost,
+//the highest cost of the tiles in the cell is used.
+func NewGridFromTileMap(tm *tentsuyu.TileMap) *Grid {
+	x0, y0, x1, y1 := 0, 0, tm.Width, tm.Height
+	for _, l := range tm.Layers {
+		if l.IsImageLayer || l.IsObjectLayer || l.Width == 0 || l.Height == 0 {
+			continue
+		}
+		if l.GridX < x0 {
+			x0 = l.GridX
+		}
+		if l.GridY < y0 {
+			y0 = l.GridY
+		}
+		if l.GridX+l.Width > x1 {
+			x1 = l.GridX + l.Width
+		}
+		if l.GridY+l.Height > y1 {
+			y1 = l.GridY + l.Height
+		}
+	}
+	g := NewGrid(x1-x0, y1-y0)
+	g.OriginX, g.OriginY = x0, y0
+	for row := y0; row < y1; row++ {
+		for col := x0; col < x1; col++ {
+			g.setCost(col, row, TileCost(tm, col, row))
+		}
+	}
+	return g
+}
+
+//TileCost returns the cost of entering the cell of the TileMap as used by NewGridFromTileMap
+func TileCost(tm *tentsuyu.TileMap, col, row int) float64 {
+	cost := 1.0
+	for _, l := range tm.Layers {
+		t := l.TileAt(col, row)
+		if t == nil {
+			continue
+		}
+		if (t.Collide && !t.OneWay && !t.IsSlope()) || (t.Properties.Has("walkable") && !t.Properties.GetBool("walkable")) {
+			return Blocked
+		}
+		if c := t.Properties.GetFloat("cost"); t.Properties.Has("cost") && c > cost {
+			cost = c
+		}
+	}
+	return cost
+}
+
+//Watch keeps the grid in step with tiles changed through TileMap.SetTile and TileMap.ClearTile
+func (g *Grid) Watch(tm *tentsuyu.TileMap) {
+	previous := tm.OnTileChange
+	tm.OnTileChange = func(layer, col, row int) {
+		if previous != nil {
+			previous(layer, col, row)
+		}
+		g.SetCost(col, row, TileCost(tm, col, row))
+	}
+}
+
+//index returns the index of the cell in costs, -1 if it is outside the grid
+func (g *Grid) index(col, row int) int {
+	col -= g.OriginX
+	row -= g.OriginY
+	if col < 0 || row < 0 || col >= g.Width || row >= g.Height {
+		return -1
+	}
+	return row*g.Width + col
+}
+
+//cell returns the column and row of the index
+func (g *Grid) cell(i int) Cell {
+	return Cell{Col: i%g.Width + g.OriginX, Row: i/g.Width + g.OriginY}
+}
+
+//InBounds returns true if the cell is part of the grid
+func (g *Grid) InBounds(col, row int) bool {
+	return g.index(col, row) >= 0
+}
+
+//Cost returns the cost of entering the cell, Blocked if it is a wall or outside the grid
+func (g *Grid) Cost(col, row int) float64 {
+	i := g.index(col, row)
+	if i < 0 {
+		return Blocked
+	}
+	return g.costs[i]
+}
+
+//Walkable returns true if the cell can be entered
+func (g *Grid) Walkable(col, row int) bool {
+	return !math.IsInf(g.Cost(col, row), 1)
+}
+
+//setCost changes the cost without telling the distance maps
+func (g *Grid) setCost(col, row int, cost float64) int {
+	i := g.index(col, row)
+	if i < 0 {
+		return -1
+	}
+	if cost < 0 || math.IsNaN(cost) {
+		cost = Blocked
+	}
+	if cost < g.minCost {
+		g.minCost = cost
+	}
+	g.costs[i] = cost
+	return i
+}
+
+//SetCost changes the cost of entering the cell. Distance maps and flow fields of the grid
+//are brought up to date the next time they are used.
+func (g *Grid) SetCost(col, row int, cost float64) {
+	old := g.Cost(col, row)
+	i := g.setCost(col, row, cost)
+	if i < 0 || g.costs[i] == old {
+		return
+	}
+	for _, d := range g.listeners {
+		d.pending = append(d.pending, i)
+	}
+}
+
+//SetBlocked makes the cell a wall, or walkable with a cost of 1
+func (g *Grid) SetBlocked(col, row int, blocked bool) {
+	if blocked {
+		g.SetCost(col, row, Blocked)
+	} else {
+		g.SetCost(col, row, 1)
+	}
+}
+
+//neighbour is a move from a cell to one next to it
+type neighbour struct {
+	dx, dy int
+	length float64
+}
+
+var neighbours = []neighbour{
+	{1, 0, 1}, {-1, 0, 1}, {0, 1, 1}, {0, -1, 1},
+	{1, 1, math.Sqrt2}, {-1, 1, math.Sqrt2}, {1, -1, math.Sqrt2}, {-1, -1, math.Sqrt2},
+}
+
+//moves returns the neighbours allowed by the Movement of the grid
+func (g *Grid) moves() []neighbour {
+	if g.Movement == EightWay {
+		return neighbours
+	}
+	return neighbours[:4]
+}
+
+//canMove returns true if the move from the cell to its neighbour follows the diagonal rule.
+//Both cells must already be known to be walkable.
+func (g *Grid) canMove(col, row int, n neighbour) bool {
+	if n.dx == 0 || n.dy == 0 {
+		return true
+	}
+	a := g.Walkable(col+n.dx, row)
+	b := g.Walkable(col, row+n.dy)
+	switch g.Diagonal {
+	case DiagonalAlways:
+		return true
+	case DiagonalOneCorner:
+		return a || b
+	}
+	return a && b
+}
+
+//heuristic estimates the cost between the cells without overestimating it
+func (g *Grid) heuristic(a, b Cell) float64 {
+	dx := math.Abs(float64(a.Col - b.Col))
+	dy := math.Abs(float64(a.Row - b.Row))
+	minCost := g.minCost
+	if minCost <= 0 {
+		return 0
+	}
+	if g.Movement == EightWay {
+		return minCost * (math.Max(dx, dy) + (math.Sqrt2-1)*math.Min(dx, dy))
+	}
+	return minCost * (dx + dy)
+}
+
+//WorldPath converts a path into the centers of its cells in world coordinates of the TileMap
+func WorldPath(tm *tentsuyu.TileMap, path []Cell) []tentsuyu.Point {
+	points := make([]tentsuyu.Point, len(path))
+	for i, c := range path {
+		x, y := tm.TileCenter(c.Col, c.Row)
+		points[i] = tentsuyu.Point{X: x, Y: y}
+	}
+	return points
+}
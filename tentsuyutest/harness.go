@@ -0,0 +1,258 @@
+//Package tentsuyutest drives a tentsuyu.Game frame by frame with scripted input
+//so GameStates can be tested without a player at the keyboard.
+package tentsuyutest
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/atolVerderben/tentsuyu"
+	"github.com/hajimehoshi/ebiten"
+)
+
+//Harness steps a Game one frame at a time using ScriptedInput and an offscreen screen image
+type Harness struct {
+	Game    *tentsuyu.Game
+	Input   *ScriptedInput
+	Screen  *ebiten.Image
+	frame   int
+	history []tentsuyu.GameState
+}
+
+//NewHarness prepares the Game for testing by replacing its InputSource with a ScriptedInput
+//and creating an offscreen screen the size of the Game's layout
+func NewHarness(g *tentsuyu.Game) (*Harness, error) {
+	w, h := g.Layout(0, 0)
+	screen, err := ebiten.NewImage(w, h, ebiten.FilterDefault)
+	if err != nil {
+		return nil, err
+	}
+	input := NewScriptedInput()
+	g.Input.SetInputSource(input)
+	hr := &Harness{
+		Game:    g,
+		Input:   input,
+		Screen:  screen,
+		history: []tentsuyu.GameState{},
+	}
+	hr.recordState()
+	return hr, nil
+}
+
+//Frame returns the number of frames that have been stepped
+func (hr *Harness) Frame() int {
+	return hr.frame
+}
+
+//Step runs a single Update and Draw of the Game
+func (hr *Harness) Step() error {
+	if err := hr.Game.Update(hr.Screen); err != nil {
+		return err
+	}
+	hr.Screen.Clear()
+	hr.Game.Draw(hr.Screen)
+	hr.frame++
+	hr.recordState()
+	return nil
+}
+
+//StepFrames runs n frames of the Game, stopping at the first error
+func (hr *Harness) StepFrames(n int) error {
+	for i := 0; i < n; i++ {
+		if err := hr.Step(); err != nil {
+			return fmt.Errorf("frame %d: %v", hr.frame, err)
+		}
+	}
+	return nil
+}
+
+//RunUntil steps the Game until cond returns true, failing after maxFrames
+func (hr *Harness) RunUntil(cond func(*tentsuyu.Game) bool, maxFrames int) error {
+	for i := 0; i < maxFrames; i++ {
+		if cond(hr.Game) {
+			return nil
+		}
+		if err := hr.Step(); err != nil {
+			return fmt.Errorf("frame %d: %v", hr.frame, err)
+		}
+	}
+	if cond(hr.Game) {
+		return nil
+	}
+	return fmt.Errorf("condition not met after %d frames", maxFrames)
+}
+
+//recordState adds the current GameState to the history if it changed
+func (hr *Harness) recordState() {
+	gs := hr.Game.GetGameState()
+	if len(hr.history) == 0 || hr.history[len(hr.history)-1] != gs {
+		hr.history = append(hr.history, gs)
+	}
+}
+
+//StateHistory returns every GameState that has been on top of the stack, in order
+func (hr *Harness) StateHistory() []tentsuyu.GameState {
+	return hr.history
+}
+
+//AssertState fails the test if the GameState on top of the stack is not want
+func (hr *Harness) AssertState(t testing.TB, want tentsuyu.GameState) {
+	t.Helper()
+	if got := hr.Game.GetGameState(); got != want {
+		t.Fatalf("frame %d: GameState is %T(%p), want %T(%p)", hr.frame, got, got, want, want)
+	}
+}
+
+//AssertStateType fails the test if the GameState on top of the stack is not the same type as want
+func (hr *Harness) AssertStateType(t testing.TB, want tentsuyu.GameState) {
+	t.Helper()
+	if got := hr.Game.GetGameState(); reflect.TypeOf(got) != reflect.TypeOf(want) {
+		t.Fatalf("frame %d: GameState is %T, want %T", hr.frame, got, want)
+	}
+}
+
+//AssertStateSequence fails the test if the types of the GameStates that have been on top of the stack
+//do not match the types of want in order
+func (hr *Harness) AssertStateSequence(t testing.TB, want ...tentsuyu.GameState) {
+	t.Helper()
+	if len(hr.history) != len(want) {
+		t.Fatalf("frame %d: %d GameStates seen, want %d: %s", hr.frame, len(hr.history), len(want), stateTypes(hr.history))
+	}
+	for i := range want {
+		if reflect.TypeOf(hr.history[i]) != reflect.TypeOf(want[i]) {
+			t.Fatalf("frame %d: GameState %d is %T, want %T: %s", hr.frame, i, hr.history[i], want[i], stateTypes(hr.history))
+		}
+	}
+}
+
+func stateTypes(states []tentsuyu.GameState) string {
+	s := "["
+	for i, gs := range states {
+		if i > 0 {
+			s += " "
+		}
+		s += fmt.Sprintf("%T", gs)
+	}
+	return s + "]"
+}
+
+//ErrNoGameLoop is returned when reading the screen outside of the ebiten game loop, see RunTests
+var ErrNoGameLoop = errors.New("tentsuyutest: the screen can only be read inside the game loop, call RunTests from TestMain")
+
+//errTestsDone ends the game loop started by RunTests
+var errTestsDone = errors.New("tentsuyutest: tests done")
+
+//inGameLoop is true while RunTests runs the tests inside the ebiten game loop
+var inGameLoop bool
+
+//RunTests runs the tests of a package inside the ebiten game loop and returns their exit code.
+//ebiten can only read pixels back from an image once the game is running, so packages using Capture or MatchGolden
+//need a TestMain such as:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(tentsuyutest.RunTests(m))
+//	}
+func RunTests(m *testing.M) int {
+	code := 0
+	f := func(*ebiten.Image) error {
+		inGameLoop = true
+		code = m.Run()
+		inGameLoop = false
+		return errTestsDone
+	}
+	if err := ebiten.Run(f, 320, 240, 1, "tentsuyutest"); err != nil && err != errTestsDone {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return code
+}
+
+//Capture copies the last drawn frame into an image.RGBA. It returns ErrNoGameLoop unless the tests are run by RunTests.
+func (hr *Harness) Capture() (*image.RGBA, error) {
+	if !inGameLoop {
+		return nil, ErrNoGameLoop
+	}
+	w, h := hr.Screen.Size()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, hr.Screen.At(x, y))
+		}
+	}
+	return img, nil
+}
+
+//MatchGolden compares the last drawn frame to the PNG at path, allowing each color channel to differ by tolerance.
+//If the file does not exist or update is true the golden file is (re)written instead.
+//Like Capture it needs the tests to be run by RunTests.
+func (hr *Harness) MatchGolden(path string, tolerance uint8, update bool) error {
+	got, err := hr.Capture()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); update || os.IsNotExist(err) {
+		return writePNG(path, got)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	want, err := png.Decode(f)
+	if err != nil {
+		return err
+	}
+	if !want.Bounds().Eq(got.Bounds()) {
+		return fmt.Errorf("%s: size %v, want %v", path, got.Bounds().Size(), want.Bounds().Size())
+	}
+	diff := 0
+	for y := got.Rect.Min.Y; y < got.Rect.Max.Y; y++ {
+		for x := got.Rect.Min.X; x < got.Rect.Max.X; x++ {
+			if !colorsClose(got.At(x, y), want.At(x, y), tolerance) {
+				diff++
+			}
+		}
+	}
+	if diff > 0 {
+		failed := path[:len(path)-len(filepath.Ext(path))] + ".failed.png"
+		writePNG(failed, got)
+		return fmt.Errorf("%s: %d pixels differ, frame written to %s", path, diff, failed)
+	}
+	return nil
+}
+
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func colorsClose(a, b color.Color, tolerance uint8) bool {
+	ca := color.RGBAModel.Convert(a).(color.RGBA)
+	cb := color.RGBAModel.Convert(b).(color.RGBA)
+	return channelClose(ca.R, cb.R, tolerance) && channelClose(ca.G, cb.G, tolerance) &&
+		channelClose(ca.B, cb.B, tolerance) && channelClose(ca.A, cb.A, tolerance)
+}
+
+func channelClose(a, b, tolerance uint8) bool {
+	if a > b {
+		return a-b <= tolerance
+	}
+	return b-a <= tolerance
+}
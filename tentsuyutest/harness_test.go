@@ -0,0 +1,127 @@
+package tentsuyutest
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/atolVerderben/tentsuyu"
+	"github.com/hajimehoshi/ebiten"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(RunTests(m))
+}
+
+//fillState fills the screen with its color and counts its updates
+type fillState struct {
+	*tentsuyu.BaseGameState
+	fill    color.RGBA
+	updates int
+	pressed int
+}
+
+func (s *fillState) Update(g *tentsuyu.Game) error {
+	s.updates++
+	if g.Input.Button("Space").JustPressed() {
+		s.pressed++
+	}
+	return nil
+}
+
+func (s *fillState) Draw(g *tentsuyu.Game) error {
+	return g.Screen.Fill(s.fill)
+}
+
+//newLoadedHarness returns a Harness for a small Game running the state, stepped past the loading screen
+func newLoadedHarness(t *testing.T, gs tentsuyu.GameState, running func() bool) *Harness {
+	t.Helper()
+	g, err := tentsuyu.NewGame(16, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.LoadImages(func() *tentsuyu.ImageManager { return g.ImageManager })
+	g.LoadAudio(func() *tentsuyu.AudioPlayer { return g.AudioPlayer })
+	g.SetGameStateLoop(func() error { return nil })
+	g.SetGameState(gs)
+	hr, err := NewHarness(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//The loaders run on goroutines, yield to them as the frames are stepped without waiting on the display
+	loaded := func(*tentsuyu.Game) bool {
+		runtime.Gosched()
+		return running()
+	}
+	if err := hr.RunUntil(loaded, 60); err != nil {
+		t.Fatal(err)
+	}
+	return hr
+}
+
+func TestHarnessStep(t *testing.T) {
+	s := &fillState{BaseGameState: tentsuyu.NewBaseGameState()}
+	hr := newLoadedHarness(t, s, func() bool { return s.updates > 0 })
+
+	frame, updates := hr.Frame(), s.updates
+	if err := hr.StepFrames(3); err != nil {
+		t.Fatal(err)
+	}
+	if hr.Frame() != frame+3 {
+		t.Errorf("Frame() = %d, want %d", hr.Frame(), frame+3)
+	}
+	if s.updates != updates+3 {
+		t.Errorf("state updated %d times in 3 frames, want 3", s.updates-updates)
+	}
+
+	hr.Input.TapKey(hr.Input.Frame()+1, ebiten.KeySpace)
+	if err := hr.StepFrames(3); err != nil {
+		t.Fatal(err)
+	}
+	if s.pressed != 1 {
+		t.Errorf("Space pressed %d times, want 1", s.pressed)
+	}
+	hr.AssertState(t, s)
+	hr.AssertStateSequence(t, s)
+}
+
+func TestHarnessRunUntil(t *testing.T) {
+	s := &fillState{BaseGameState: tentsuyu.NewBaseGameState()}
+	hr := newLoadedHarness(t, s, func() bool { return s.updates > 0 })
+
+	if err := hr.RunUntil(func(*tentsuyu.Game) bool { return s.updates >= 10 }, 20); err != nil {
+		t.Fatal(err)
+	}
+	if s.updates != 10 {
+		t.Errorf("RunUntil stopped after %d updates, want 10", s.updates)
+	}
+	if err := hr.RunUntil(func(*tentsuyu.Game) bool { return false }, 5); err == nil {
+		t.Error("RunUntil returned nil for a condition that is never met")
+	}
+}
+
+func TestHarnessMatchGolden(t *testing.T) {
+	s := &fillState{BaseGameState: tentsuyu.NewBaseGameState(), fill: color.RGBA{0xff, 0, 0, 0xff}}
+	hr := newLoadedHarness(t, s, func() bool { return s.updates > 0 })
+	golden := filepath.Join(t.TempDir(), "fill.png")
+
+	if err := hr.MatchGolden(golden, 0, false); err != nil {
+		t.Fatalf("writing the golden file: %v", err)
+	}
+	if _, err := os.Stat(golden); err != nil {
+		t.Fatalf("golden file not written: %v", err)
+	}
+	if err := hr.MatchGolden(golden, 0, false); err != nil {
+		t.Errorf("unchanged frame does not match: %v", err)
+	}
+
+	s.fill = color.RGBA{0, 0, 0xff, 0xff}
+	if err := hr.Step(); err != nil {
+		t.Fatal(err)
+	}
+	if err := hr.MatchGolden(golden, 8, false); err == nil {
+		t.Error("changed frame matches the golden file")
+	}
+}
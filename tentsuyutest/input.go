@@ -0,0 +1,131 @@
+package tentsuyutest
+
+import (
+	"github.com/atolVerderben/tentsuyu"
+	"github.com/hajimehoshi/ebiten"
+)
+
+var _ tentsuyu.InputSource = (*ScriptedInput)(nil)
+
+//inputEvent changes the scripted input state at the start of a frame
+type inputEvent func(s *ScriptedInput)
+
+//ScriptedInput is an InputSource whose state is changed by events scheduled for specific frames.
+//Frames are counted by Poll, which the InputController calls once per update.
+type ScriptedInput struct {
+	frame          int
+	events         map[int][]inputEvent
+	keys           map[ebiten.Key]bool
+	mouseButtons   map[ebiten.MouseButton]bool
+	cursorX        int
+	cursorY        int
+	wheelX, wheelY float64
+	chars          []rune
+}
+
+//NewScriptedInput returns a ScriptedInput with nothing pressed
+func NewScriptedInput() *ScriptedInput {
+	return &ScriptedInput{
+		frame:        -1,
+		events:       map[int][]inputEvent{},
+		keys:         map[ebiten.Key]bool{},
+		mouseButtons: map[ebiten.MouseButton]bool{},
+	}
+}
+
+//Frame returns the frame the input is currently on
+func (s *ScriptedInput) Frame() int {
+	return s.frame
+}
+
+//at schedules an event for the given frame
+func (s *ScriptedInput) at(frame int, e inputEvent) *ScriptedInput {
+	s.events[frame] = append(s.events[frame], e)
+	return s
+}
+
+//PressKey holds down the key starting at frame
+func (s *ScriptedInput) PressKey(frame int, k ebiten.Key) *ScriptedInput {
+	return s.at(frame, func(s *ScriptedInput) { s.keys[k] = true })
+}
+
+//ReleaseKey lets go of the key at frame
+func (s *ScriptedInput) ReleaseKey(frame int, k ebiten.Key) *ScriptedInput {
+	return s.at(frame, func(s *ScriptedInput) { delete(s.keys, k) })
+}
+
+//TapKey presses the key at frame and releases it on the following frame
+func (s *ScriptedInput) TapKey(frame int, k ebiten.Key) *ScriptedInput {
+	return s.PressKey(frame, k).ReleaseKey(frame+1, k)
+}
+
+//HoldKey presses the key at frame and releases it after the given number of frames
+func (s *ScriptedInput) HoldKey(frame, frames int, k ebiten.Key) *ScriptedInput {
+	return s.PressKey(frame, k).ReleaseKey(frame+frames, k)
+}
+
+//MoveMouse moves the cursor to the screen position x,y at frame
+func (s *ScriptedInput) MoveMouse(frame, x, y int) *ScriptedInput {
+	return s.at(frame, func(s *ScriptedInput) { s.cursorX, s.cursorY = x, y })
+}
+
+//PressMouse holds down the mouse button starting at frame
+func (s *ScriptedInput) PressMouse(frame int, b ebiten.MouseButton) *ScriptedInput {
+	return s.at(frame, func(s *ScriptedInput) { s.mouseButtons[b] = true })
+}
+
+//ReleaseMouse lets go of the mouse button at frame
+func (s *ScriptedInput) ReleaseMouse(frame int, b ebiten.MouseButton) *ScriptedInput {
+	return s.at(frame, func(s *ScriptedInput) { delete(s.mouseButtons, b) })
+}
+
+//Click moves the cursor to x,y and clicks the mouse button at frame, releasing it on the following frame
+func (s *ScriptedInput) Click(frame, x, y int, b ebiten.MouseButton) *ScriptedInput {
+	return s.MoveMouse(frame, x, y).PressMouse(frame, b).ReleaseMouse(frame+1, b)
+}
+
+//Scroll moves the mouse wheel by dx,dy during frame only
+func (s *ScriptedInput) Scroll(frame int, dx, dy float64) *ScriptedInput {
+	return s.at(frame, func(s *ScriptedInput) { s.wheelX, s.wheelY = dx, dy })
+}
+
+//Type inputs the characters of text during frame only
+func (s *ScriptedInput) Type(frame int, text string) *ScriptedInput {
+	return s.at(frame, func(s *ScriptedInput) { s.chars = append(s.chars, []rune(text)...) })
+}
+
+//Poll advances to the next frame and applies its scheduled events
+func (s *ScriptedInput) Poll() {
+	s.frame++
+	s.wheelX, s.wheelY = 0, 0
+	s.chars = s.chars[:0]
+	for _, e := range s.events[s.frame] {
+		e(s)
+	}
+	delete(s.events, s.frame)
+}
+
+//IsKeyPressed returns true if the key is currently held
+func (s *ScriptedInput) IsKeyPressed(k ebiten.Key) bool {
+	return s.keys[k]
+}
+
+//IsMouseButtonPressed returns true if the mouse button is currently held
+func (s *ScriptedInput) IsMouseButtonPressed(b ebiten.MouseButton) bool {
+	return s.mouseButtons[b]
+}
+
+//CursorPosition returns the scripted cursor position
+func (s *ScriptedInput) CursorPosition() (int, int) {
+	return s.cursorX, s.cursorY
+}
+
+//Wheel returns the scripted wheel offsets for this frame
+func (s *ScriptedInput) Wheel() (float64, float64) {
+	return s.wheelX, s.wheelY
+}
+
+//InputChars returns the characters typed during this frame
+func (s *ScriptedInput) InputChars() []rune {
+	return s.chars
+}
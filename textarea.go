@@ -39,7 +39,7 @@ func (ta *TextArea) Update(input *InputController) {
 				ta.SetText(ta.text)
 			}
 		}
-		ta.text[ta.Lines-1] += string(ebiten.InputChars())
+		ta.text[ta.Lines-1] += string(input.InputChars())
 		ta.SetText(ta.text)
 
 	}
@@ -44,7 +44,7 @@ func (tb *TextBox) Update(input *InputController) {
 		}
 	}
 	if tb.Selected {
-		tb.Text.text[0] += string(ebiten.InputChars())
+		tb.Text.text[0] += string(input.InputChars())
 		tb.Text.SetText(tb.Text.text)
 		if input.keyManager.Get(ebiten.KeyEnter).JustPressed() || input.keyManager.Get(ebiten.KeyKPEnter).JustPressed() {
 			tb.Text.UnHighlighted()
@@ -1,8 +1,6 @@
 package tentsuyu
 
 import (
-	"log"
-
 	"image/color"
 
 	"github.com/golang/freetype/truetype"
@@ -28,10 +26,8 @@ type TextElement struct {
 
 //NewTextElement returns a new TextElement and creates the image
 func NewTextElement(x, y float64, w, h int, fnt *truetype.Font, text []string, textColor color.Color, fntSize float64) *TextElement {
-	textImage, err := ebiten.NewImage(w, h, ebiten.FilterNearest)
-	if err != nil {
-		log.Fatal(err)
-	}
+	//NewImage has not returned an error since ebiten 1.5
+	textImage, _ := ebiten.NewImage(w, h, ebiten.FilterNearest)
 	t := &TextElement{
 		font:           fnt,
 		fntSize:        fntSize,
@@ -64,10 +60,8 @@ func NewTextElementCentered(x, y float64, w, h int, fnt *truetype.Font, text []s
 
 //NewTextElementStationary returns a new TextElement and creates the image
 func NewTextElementStationary(x, y float64, w, h int, fnt *truetype.Font, text []string, textColor color.Color, fntSize float64) *TextElement {
-	textImage, err := ebiten.NewImage(w, h, ebiten.FilterNearest)
-	if err != nil {
-		log.Fatal(err)
-	}
+	//NewImage has not returned an error since ebiten 1.5
+	textImage, _ := ebiten.NewImage(w, h, ebiten.FilterNearest)
 	t := &TextElement{
 		font:           fnt,
 		fntSize:        fntSize,
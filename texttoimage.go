@@ -3,7 +3,6 @@ package tentsuyu
 import (
 	"image"
 	"image/color"
-	"log"
 
 	"github.com/golang/freetype/truetype"
 	"github.com/hajimehoshi/ebiten"
@@ -16,7 +15,7 @@ func RenderTextToImage(text []string, w, h int, fntSize float64, fnt *truetype.F
 
 	drawImage, err := ebiten.NewImage(w, h, ebiten.FilterNearest)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	//w, h := t.GetSize()
 	dst := image.NewRGBA(image.Rect(0, 0, w, h))
@@ -0,0 +1,130 @@
+package tentsuyu
+
+import "encoding/json"
+
+//TileFrame is a single frame of a tile animation defined in a Tiled TileSet.
+//TileID is the local id of the tile to show and Duration is in milliseconds.
+type TileFrame struct {
+	TileID   int `json:"tileid" xml:"tileid,attr"`
+	Duration int `json:"duration" xml:"duration,attr"`
+}
+
+//UnmarshalJSON reads the tile, using the newer "class" field of Tiled when "type" is not set
+func (t *TileSetTile) UnmarshalJSON(b []byte) error {
+	type tileAlias TileSetTile
+	aux := struct {
+		*tileAlias
+		Class string `json:"class"`
+	}{
+		tileAlias: (*tileAlias)(t),
+	}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	if t.Type == "" {
+		t.Type = aux.Class
+	}
+	return nil
+}
+
+//TileAnimation plays the frames of an animated tile.
+//A single TileAnimation is shared by every Tile in a TileMap with the same GID so they stay in sync.
+type TileAnimation struct {
+	Frames  []*TileAnimationFrame
+	current int
+	elapsed float64
+}
+
+//TileAnimationFrame is a frame of a TileAnimation with its position in the TileSet image
+type TileAnimationFrame struct {
+	Gid      int
+	Sx, Sy   int
+	Duration int
+}
+
+//newTileAnimation builds the TileAnimation for the frames of a tile in the TileSet
+func newTileAnimation(ts *TileSet, frames []*TileFrame) *TileAnimation {
+	a := &TileAnimation{}
+	for _, f := range frames {
+		gid := ts.FirstGID + f.TileID
+		sx, sy := ts.ReturnImagePosition(gid)
+		a.Frames = append(a.Frames, &TileAnimationFrame{
+			Gid:      gid,
+			Sx:       int(sx),
+			Sy:       int(sy),
+			Duration: f.Duration,
+		})
+	}
+	return a
+}
+
+//Update advances the animation by dt seconds
+func (a *TileAnimation) Update(dt float64) {
+	if len(a.Frames) < 2 {
+		return
+	}
+	a.elapsed += dt * 1000
+	for i := 0; i < len(a.Frames); i++ {
+		d := float64(a.Frames[a.current].Duration)
+		if d <= 0 || a.elapsed < d {
+			return
+		}
+		a.elapsed -= d
+		a.current = (a.current + 1) % len(a.Frames)
+	}
+	//More than a full loop passed in one update, drop the remainder
+	a.elapsed = 0
+}
+
+//CurrentFrame returns the frame currently being shown
+func (a *TileAnimation) CurrentFrame() *TileAnimationFrame {
+	if len(a.Frames) == 0 {
+		return nil
+	}
+	return a.Frames[a.current]
+}
+
+//Reset the animation to its first frame
+func (a *TileAnimation) Reset() {
+	a.current = 0
+	a.elapsed = 0
+}
+
+//tileAnimation returns the shared TileAnimation for the gid, creating it the first time
+func (tm *TileMap) tileAnimation(ts *TileSet, gid int, frames []*TileFrame) *TileAnimation {
+	if tm.animations == nil {
+		tm.animations = map[int]*TileAnimation{}
+	}
+	if a, ok := tm.animations[gid]; ok {
+		return a
+	}
+	a := newTileAnimation(ts, frames)
+	tm.animations[gid] = a
+	return a
+}
+
+//Update the animated tiles of the TileMap by a single tick
+func (tm *TileMap) Update() {
+	tm.UpdateDelta(1.0 / referenceTPS)
+}
+
+//UpdateDelta advances the animated tiles of the TileMap by dt seconds.
+//Pass Clock.SubsystemDelta(ClockAnimation) to have them follow the game's time scale.
+func (tm *TileMap) UpdateDelta(dt float64) {
+	for _, a := range tm.animations {
+		a.Update(dt)
+	}
+}
+
+//CollisionShapes returns the collision objects Tiled defines for the tile, relative to its top left corner
+func (t *Tile) CollisionShapes() []*MapObject {
+	if t.TileData == nil || t.TileData.ObjectGroup == nil {
+		return nil
+	}
+	return t.TileData.ObjectGroup.Objects
+}
+
+//IsAnimated returns true if the tile plays a Tiled tile animation
+func (t *Tile) IsAnimated() bool {
+	return t.Animation != nil
+}
@@ -0,0 +1,310 @@
+package tentsuyu
+
+import "math"
+
+//collisionEpsilon is how far an edge may sit inside a tile before it counts as overlapping
+const collisionEpsilon = 0.001
+
+//TileContact is a solid tile touched by a query or a move.
+//NormalX, NormalY point away from the tile and are 0 for plain queries.
+type TileContact struct {
+	Tile             *Tile
+	Layer            *TileLayer
+	Col, Row         int
+	NormalX, NormalY float64
+}
+
+//TileMoveResult is the outcome of moving an object through a TileMap with MoveObject
+type TileMoveResult struct {
+	DX, DY      float64
+	Contacts    []*TileContact
+	OnGround    bool
+	OnCeiling   bool
+	OnLeftWall  bool
+	OnRightWall bool
+	OnSlope     bool
+}
+
+//applyCollisionProperties reads the collision settings of the tile from its Tiled properties.
+//"collide" or "solid" make the tile solid, "oneway" makes it only block from above and
+//"slopeleft"/"sloperight" are the heights in pixels of the floor at the left and right edge of the tile.
+func (t *Tile) applyCollisionProperties() {
+	ps := t.Properties
+	t.Collide = ps.GetBool("collide") || ps.GetBool("solid")
+	t.OneWay = ps.GetBool("oneway")
+	t.SlopeLeft, t.SlopeRight = 0, 0
+	if ps.Has("slopeleft") || ps.Has("sloperight") {
+		t.SlopeLeft = ps.GetFloat("slopeleft")
+		t.SlopeRight = ps.GetFloat("sloperight")
+	}
+	if t.OneWay || t.IsSlope() {
+		t.Collide = true
+	}
+}
+
+//IsSlope returns true if the floor of the tile is sloped
+func (t *Tile) IsSlope() bool {
+	return t.SlopeLeft != 0 || t.SlopeRight != 0
+}
+
+//tileBox is a solid rectangle of a tile in world coordinates
+type tileBox struct {
+	left, top, right, bottom float64
+}
+
+//cellBox returns the world rectangle of the cell at the column and row
+func (tl *TileLayer) cellBox(col, row int) tileBox {
+	x := float64(col*tl.TileWidth) + tl.OffsetX
+	y := float64(row*tl.TileHeight) + tl.OffsetY
+	return tileBox{x, y, x + float64(tl.TileWidth), y + float64(tl.TileHeight)}
+}
+
+//solidBoxes returns the solid rectangles of the tile. Rectangles from the collision shapes
+//of the tile in Tiled are used when there are any, otherwise the whole cell is solid.
+func (tl *TileLayer) solidBoxes(t *Tile, col, row int) []tileBox {
+	boxes := []tileBox{}
+	for _, s := range t.CollisionShapes() {
+		if s.Ellipse || s.Point || s.Polygon != nil || s.Polyline != nil || s.Width <= 0 || s.Height <= 0 {
+			continue
+		}
+		x, y := t.GetX()+s.X, t.GetY()+s.Y
+		boxes = append(boxes, tileBox{x, y, x + s.Width, y + s.Height})
+	}
+	if len(boxes) == 0 {
+		boxes = append(boxes, tl.cellBox(col, row))
+	}
+	return boxes
+}
+
+//slopeSurface returns the y of the floor of a slope tile at the world x, clamped to the cell
+func (tl *TileLayer) slopeSurface(t *Tile, col, row int, x float64) float64 {
+	cell := tl.cellBox(col, row)
+	u := (x - cell.left) / float64(tl.TileWidth)
+	u = math.Max(0, math.Min(1, u))
+	return cell.bottom - (t.SlopeLeft + (t.SlopeRight-t.SlopeLeft)*u)
+}
+
+//slopeNormal returns the unit normal pointing out of the floor of a slope tile
+func (tl *TileLayer) slopeNormal(t *Tile) (float64, float64) {
+	m := -(t.SlopeRight - t.SlopeLeft) / float64(tl.TileWidth)
+	l := math.Hypot(m, 1)
+	return m / l, -1 / l
+}
+
+//cellRange returns the columns and rows of the layer overlapping the world rectangle, end exclusive
+func (tl *TileLayer) cellRange(left, top, right, bottom float64) (col0, row0, col1, row1 int) {
+	//Collision is only resolved on orthogonal grids
+	if tl.grid == nil || tl.TileWidth <= 0 || tl.TileHeight <= 0 || !tl.layout.orthogonal() {
+		return 0, 0, 0, 0
+	}
+	tw, th := float64(tl.TileWidth), float64(tl.TileHeight)
+	col0 = maxInt(int(math.Floor((left-tl.OffsetX)/tw)), tl.GridX)
+	row0 = maxInt(int(math.Floor((top-tl.OffsetY)/th)), tl.GridY)
+	col1 = minInt(int(math.Ceil((right-tl.OffsetX)/tw)), tl.GridX+tl.Width)
+	row1 = minInt(int(math.Ceil((bottom-tl.OffsetY)/th)), tl.GridY+tl.Height)
+	return col0, row0, col1, row1
+}
+
+//eachSolid calls fn for every solid tile of the tile layers whose cell overlaps the rectangle
+func (tm *TileMap) eachSolid(left, top, right, bottom float64, fn func(tl *TileLayer, t *Tile, col, row int)) {
+	for _, tl := range tm.Layers {
+		col0, row0, col1, row1 := tl.cellRange(left, top, right, bottom)
+		for row := row0; row < row1; row++ {
+			for col := col0; col < col1; col++ {
+				t := tl.grid[(row-tl.GridY)*tl.Width+col-tl.GridX]
+				if t != nil && t.Collide {
+					fn(tl, t, col, row)
+				}
+			}
+		}
+	}
+}
+
+//overlaps returns true if the rectangle overlaps the solid part of the tile
+func (tl *TileLayer) overlaps(t *Tile, col, row int, left, top, right, bottom float64) bool {
+	if t.IsSlope() {
+		cell := tl.cellBox(col, row)
+		if right <= cell.left || left >= cell.right || bottom <= cell.top || top >= cell.bottom {
+			return false
+		}
+		//The surface is a straight line so its highest point over the rectangle is at one of the ends
+		surface := math.Min(tl.slopeSurface(t, col, row, left), tl.slopeSurface(t, col, row, right))
+		return bottom > surface
+	}
+	for _, b := range tl.solidBoxes(t, col, row) {
+		if right > b.left && left < b.right && bottom > b.top && top < b.bottom {
+			return true
+		}
+	}
+	return false
+}
+
+//SolidTilesIn returns every solid tile overlapping the world rectangle, including one-way platforms and slopes
+func (tm *TileMap) SolidTilesIn(x, y, w, h float64) []*TileContact {
+	contacts := []*TileContact{}
+	tm.eachSolid(x, y, x+w, y+h, func(tl *TileLayer, t *Tile, col, row int) {
+		if tl.overlaps(t, col, row, x, y, x+w, y+h) {
+			contacts = append(contacts, &TileContact{Tile: t, Layer: tl, Col: col, Row: row})
+		}
+	})
+	return contacts
+}
+
+//IsSolidAt returns true if the world point is inside a solid tile
+func (tm *TileMap) IsSolidAt(x, y float64) bool {
+	solid := false
+	tm.eachSolid(x, y, x+collisionEpsilon, y+collisionEpsilon, func(tl *TileLayer, t *Tile, col, row int) {
+		if !solid && tl.overlaps(t, col, row, x, y, x+collisionEpsilon, y+collisionEpsilon) {
+			solid = true
+		}
+	})
+	return solid
+}
+
+//axisHits keeps the contacts with the largest correction found while resolving a single axis
+type axisHits struct {
+	contacts   []*TileContact
+	correction float64
+}
+
+//add the contact, keeping only the contacts that need the biggest push back
+func (h *axisHits) add(c *TileContact, correction float64) {
+	switch {
+	case len(h.contacts) == 0 || math.Abs(correction) > math.Abs(h.correction)+collisionEpsilon:
+		h.contacts = []*TileContact{c}
+		h.correction = correction
+	case math.Abs(correction) >= math.Abs(h.correction)-collisionEpsilon:
+		h.contacts = append(h.contacts, c)
+	}
+}
+
+//objectBounds returns the edges of the object respecting NotCentered
+func objectBounds(obj *BasicObject) (left, top, right, bottom float64) {
+	return obj.Left(), obj.Top(), obj.Right(), obj.Bottom()
+}
+
+//MoveObject moves the object by dx, dy resolving collisions with the solid tiles of the TileMap
+//one axis at a time, first x then y. Each axis is swept from the old to the new position so the object
+//stops at the first tile in its way however far it moves. One-way platforms only block objects falling onto them from above
+//and slopes lift the object onto their floor, keeping it on the slope when walking down.
+func (tm *TileMap) MoveObject(obj *BasicObject, dx, dy float64) *TileMoveResult {
+	res := &TileMoveResult{Contacts: []*TileContact{}}
+	startX, startY := obj.GetPosition()
+
+	if dx != 0 {
+		oldLeft, _, oldRight, _ := objectBounds(obj)
+		obj.AddX(dx)
+		left, top, right, bottom := objectBounds(obj)
+		//Sweep the whole path so a fast object cannot pass through a tile thinner than its move
+		sweepLeft, sweepRight := math.Min(oldLeft, left), math.Max(oldRight, right)
+		hits := &axisHits{}
+		tm.eachSolid(sweepLeft, top, sweepRight, bottom, func(tl *TileLayer, t *Tile, col, row int) {
+			if t.OneWay || t.IsSlope() {
+				return
+			}
+			for _, b := range tl.solidBoxes(t, col, row) {
+				if !(sweepRight > b.left && sweepLeft < b.right && bottom > b.top && top < b.bottom) {
+					continue
+				}
+				if dx > 0 && b.left >= oldRight-collisionEpsilon {
+					hits.add(&TileContact{Tile: t, Layer: tl, Col: col, Row: row, NormalX: -1}, b.left-right)
+				} else if dx < 0 && b.right <= oldLeft+collisionEpsilon {
+					hits.add(&TileContact{Tile: t, Layer: tl, Col: col, Row: row, NormalX: 1}, b.right-left)
+				}
+			}
+		})
+		if len(hits.contacts) > 0 {
+			obj.AddX(hits.correction)
+			res.Contacts = append(res.Contacts, hits.contacts...)
+			if dx > 0 {
+				res.OnRightWall = true
+			} else {
+				res.OnLeftWall = true
+			}
+		}
+	}
+
+	if dy != 0 {
+		_, oldTop, _, oldBottom := objectBounds(obj)
+		obj.AddY(dy)
+		left, top, right, bottom := objectBounds(obj)
+		sweepTop, sweepBottom := math.Min(oldTop, top), math.Max(oldBottom, bottom)
+		hits := &axisHits{}
+		tm.eachSolid(left, sweepTop, right, sweepBottom, func(tl *TileLayer, t *Tile, col, row int) {
+			if t.IsSlope() || (t.OneWay && dy < 0) {
+				return
+			}
+			for _, b := range tl.solidBoxes(t, col, row) {
+				if !(right > b.left && left < b.right && sweepBottom > b.top && sweepTop < b.bottom) {
+					continue
+				}
+				if dy > 0 && b.top >= oldBottom-collisionEpsilon {
+					hits.add(&TileContact{Tile: t, Layer: tl, Col: col, Row: row, NormalY: -1}, b.top-bottom)
+				} else if dy < 0 && b.bottom <= oldTop+collisionEpsilon {
+					hits.add(&TileContact{Tile: t, Layer: tl, Col: col, Row: row, NormalY: 1}, b.bottom-top)
+				}
+			}
+		})
+		if len(hits.contacts) > 0 {
+			obj.AddY(hits.correction)
+			res.Contacts = append(res.Contacts, hits.contacts...)
+			if dy > 0 {
+				res.OnGround = true
+			} else {
+				res.OnCeiling = true
+			}
+		}
+	}
+
+	if dy >= 0 {
+		tm.resolveSlopes(obj, math.Abs(dx), res)
+	}
+
+	x, y := obj.GetPosition()
+	res.DX, res.DY = x-startX, y-startY
+	return res
+}
+
+//resolveSlopes places the bottom of the object on the floor of a slope under its center.
+//Objects above a slope by less than the height it drops over maxStep are pulled down onto it.
+func (tm *TileMap) resolveSlopes(obj *BasicObject, maxStep float64, res *TileMoveResult) {
+	left, _, right, bottom := objectBounds(obj)
+	footX := (left + right) / 2
+	var best *TileContact
+	bestSurface := 0.0
+	for _, tl := range tm.Layers {
+		if tl.grid == nil || tl.TileHeight <= 0 || !tl.layout.orthogonal() {
+			continue
+		}
+		th := float64(tl.TileHeight)
+		col0, row0, col1, row1 := tl.cellRange(footX, bottom-th, footX+collisionEpsilon, bottom+th)
+		for row := row0; row < row1; row++ {
+			for col := col0; col < col1; col++ {
+				t := tl.grid[(row-tl.GridY)*tl.Width+col-tl.GridX]
+				if t == nil || !t.Collide || !t.IsSlope() {
+					continue
+				}
+				surface := tl.slopeSurface(t, col, row, footX)
+				drop := math.Abs(t.SlopeRight-t.SlopeLeft) / float64(tl.TileWidth) * maxStep
+				penetrating := bottom > surface && bottom-surface <= th
+				snapping := bottom <= surface && surface-bottom <= drop+collisionEpsilon
+				if !penetrating && !snapping {
+					continue
+				}
+				//Prefer the highest floor so the object never ends up inside a slope
+				if best == nil || surface < bestSurface {
+					nx, ny := tl.slopeNormal(t)
+					best = &TileContact{Tile: t, Layer: tl, Col: col, Row: row, NormalX: nx, NormalY: ny}
+					bestSurface = surface
+				}
+			}
+		}
+	}
+	if best == nil {
+		return
+	}
+	obj.AddY(bestSurface - bottom)
+	res.Contacts = append(res.Contacts, best)
+	res.OnGround = true
+	res.OnSlope = true
+}
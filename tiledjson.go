@@ -0,0 +1,217 @@
+package tentsuyu
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+//Flags stored in the highest bits of a Tiled GID
+const (
+	FlippedHorizontallyFlag uint32 = 0x80000000
+	FlippedVerticallyFlag   uint32 = 0x40000000
+	FlippedDiagonallyFlag   uint32 = 0x20000000
+	RotatedHexagonal120Flag uint32 = 0x10000000
+	gidFlagMask                    = FlippedHorizontallyFlag | FlippedVerticallyFlag | FlippedDiagonallyFlag | RotatedHexagonal120Flag
+)
+
+//DecodeGID separates a raw Tiled GID into the tile id and its flip flags
+func DecodeGID(raw uint32) (gid int, flipH, flipV, flipD bool) {
+	return int(raw &^ gidFlagMask),
+		raw&FlippedHorizontallyFlag != 0,
+		raw&FlippedVerticallyFlag != 0,
+		raw&FlippedDiagonallyFlag != 0
+}
+
+//EncodeGID combines a tile id and flip flags back into a raw Tiled GID
+func EncodeGID(gid int, flipH, flipV, flipD bool) uint32 {
+	raw := uint32(gid)
+	if flipH {
+		raw |= FlippedHorizontallyFlag
+	}
+	if flipV {
+		raw |= FlippedVerticallyFlag
+	}
+	if flipD {
+		raw |= FlippedDiagonallyFlag
+	}
+	return raw
+}
+
+//UnmarshalJSON reads the map accepting the version as either a number or a string
+func (m *Map) UnmarshalJSON(b []byte) error {
+	type mapAlias Map
+	aux := struct {
+		*mapAlias
+		Version json.RawMessage `json:"version"`
+	}{
+		mapAlias: (*mapAlias)(m),
+	}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	m.Version = propertyValueString(aux.Version)
+	return nil
+}
+
+//UnmarshalJSON reads the layer, decoding the tile data from either a plain array or base64 with optional compression
+func (l *Layer) UnmarshalJSON(b []byte) error {
+	type layerAlias Layer
+	aux := struct {
+		*layerAlias
+		Data json.RawMessage `json:"data"`
+	}{
+		layerAlias: (*layerAlias)(l),
+	}
+	//Tiled omits these when they are at their defaults
+	l.Visible = true
+	l.Opacity = 1
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	data, err := decodeLayerData(aux.Data, l.Encoding, l.Compression)
+	if err != nil {
+		return fmt.Errorf("layer %q: %v", l.Name, err)
+	}
+	l.Data = data
+	for _, c := range l.Chunks {
+		if c.Data, err = decodeLayerData(c.RawData, l.Encoding, l.Compression); err != nil {
+			return fmt.Errorf("layer %q chunk %d,%d: %v", l.Name, c.X, c.Y, err)
+		}
+		c.RawData = nil
+	}
+	return nil
+}
+
+//UnmarshalJSON reads the object setting Visible when Tiled has omitted it
+func (o *MapObject) UnmarshalJSON(b []byte) error {
+	type objectAlias MapObject
+	o.Visible = true
+	return json.Unmarshal(b, (*objectAlias)(o))
+}
+
+//MarshalJSON writes the chunk with its tile data as a plain array of GIDs
+func (c *Chunk) MarshalJSON() ([]byte, error) {
+	type chunkAlias Chunk
+	aux := struct {
+		*chunkAlias
+		Data []int `json:"data"`
+	}{
+		chunkAlias: (*chunkAlias)(c),
+		Data:       c.Data,
+	}
+	if len(c.RawData) > 0 && c.Data == nil {
+		return json.Marshal((*chunkAlias)(c))
+	}
+	//The outer Data hides the RawData of the alias
+	return json.Marshal(aux)
+}
+
+//MarshalJSON writes only the reference to an external tileset, or the whole tileset when it is embedded in the map
+func (ts *TileSet) MarshalJSON() ([]byte, error) {
+	if ts.Source != "" {
+		return json.Marshal(struct {
+			FirstGID int    `json:"firstgid"`
+			Source   string `json:"source"`
+		}{ts.FirstGID, ts.Source})
+	}
+	type tileSetAlias TileSet
+	return json.Marshal((*tileSetAlias)(ts))
+}
+
+//decodeLayerData converts Tiled layer data into raw GIDs
+func decodeLayerData(raw json.RawMessage, encoding, compression string) ([]int, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	if encoding == "" || encoding == "csv" {
+		data := []int{}
+		if raw[0] == '"' {
+			//csv data from a tmx converted with the encoding kept
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return nil, err
+			}
+			return decodeCSVData(s)
+		}
+		//GIDs with flip flags set overflow int32 so decode through uint32
+		var gids []uint32
+		if err := json.Unmarshal(raw, &gids); err != nil {
+			return nil, err
+		}
+		for _, gid := range gids {
+			data = append(data, int(gid))
+		}
+		return data, nil
+	}
+	if encoding != "base64" {
+		return nil, fmt.Errorf("unsupported encoding %q", encoding)
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, err
+	}
+	return decodeBase64Data(s, compression)
+}
+
+//decodeBase64Data decodes base64 layer data with optional zlib or gzip compression
+func decodeBase64Data(s, compression string) ([]int, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	switch compression {
+	case "":
+	case "zlib":
+		r, err := zlib.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		if b, err = ioutil.ReadAll(r); err != nil {
+			return nil, err
+		}
+	case "gzip":
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		if b, err = ioutil.ReadAll(r); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", compression)
+	}
+	if len(b)%4 != 0 {
+		return nil, fmt.Errorf("layer data length %d is not a multiple of 4", len(b))
+	}
+	data := make([]int, len(b)/4)
+	for i := range data {
+		data[i] = int(binary.LittleEndian.Uint32(b[i*4:]))
+	}
+	return data, nil
+}
+
+//decodeCSVData decodes comma separated GIDs
+func decodeCSVData(s string) ([]int, error) {
+	data := []int{}
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		var gid uint32
+		if _, err := fmt.Sscan(field, &gid); err != nil {
+			return nil, err
+		}
+		data = append(data, int(gid))
+	}
+	return data, nil
+}
@@ -0,0 +1,216 @@
+package tentsuyu
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//Tiled property types
+const (
+	PropertyString = "string"
+	PropertyInt    = "int"
+	PropertyFloat  = "float"
+	PropertyBool   = "bool"
+	PropertyColor  = "color"
+	PropertyFile   = "file"
+	PropertyObject = "object"
+)
+
+//UnmarshalJSON accepts the value of the property as any JSON type and keeps its string form in Value
+func (p *Property) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Name     string          `json:"name"`
+		PropType string          `json:"type"`
+		Value    json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	p.Name = raw.Name
+	p.PropType = raw.PropType
+	if p.PropType == "" {
+		p.PropType = PropertyString
+	}
+	p.Value = propertyValueString(raw.Value)
+	return nil
+}
+
+//MarshalJSON writes the property in the Tiled format with a value of the correct JSON type
+func (p Property) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Name     string      `json:"name"`
+		PropType string      `json:"type"`
+		Value    interface{} `json:"value"`
+	}{
+		Name:     p.Name,
+		PropType: p.PropType,
+		Value:    p.Value,
+	}
+	switch p.PropType {
+	case PropertyInt, PropertyObject:
+		out.Value = p.Int()
+	case PropertyFloat:
+		out.Value = p.Float()
+	case PropertyBool:
+		out.Value = p.Bool()
+	}
+	return json.Marshal(out)
+}
+
+//propertyValueString converts a raw JSON value into the string stored in Property.Value
+func propertyValueString(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
+}
+
+//String returns the value of the property as a string
+func (p *Property) String() string {
+	return p.Value
+}
+
+//Int returns the value of the property as an int, 0 if it cannot be converted
+func (p *Property) Int() int {
+	if i, err := strconv.Atoi(p.Value); err == nil {
+		return i
+	}
+	return int(p.Float())
+}
+
+//Float returns the value of the property as a float64, 0 if it cannot be converted
+func (p *Property) Float() float64 {
+	f, _ := strconv.ParseFloat(p.Value, 64)
+	return f
+}
+
+//Bool returns the value of the property as a bool, false if it cannot be converted
+func (p *Property) Bool() bool {
+	b, _ := strconv.ParseBool(p.Value)
+	return b
+}
+
+//Color returns the value of a color property. Tiled stores colors as #AARRGGBB or #RRGGBB.
+func (p *Property) Color() color.Color {
+	c, _ := ParseTiledColor(p.Value)
+	return c
+}
+
+//ParseTiledColor converts a Tiled color string (#AARRGGBB or #RRGGBB) into a color.RGBA
+func ParseTiledColor(s string) (color.RGBA, bool) {
+	s = strings.TrimPrefix(s, "#")
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.RGBA{}, false
+	}
+	switch len(s) {
+	case 6:
+		return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, true
+	case 8:
+		return color.RGBA{A: uint8(v >> 24), R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v)}, true
+	}
+	return color.RGBA{}, false
+}
+
+//tiledColorString converts a color into a Tiled color string, the opposite of ParseTiledColor
+func tiledColorString(c color.Color) string {
+	if c == nil {
+		return ""
+	}
+	r, g, b, a := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x%02x", a>>8, r>>8, g>>8, b>>8)
+}
+
+//Properties is a list of custom Tiled properties
+type Properties []*Property
+
+//UnmarshalJSON accepts both the current Tiled property array and the older name to value object
+func (ps *Properties) UnmarshalJSON(b []byte) error {
+	var list []*Property
+	if err := json.Unmarshal(b, &list); err == nil {
+		*ps = list
+		return nil
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(b, &values); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	*ps = make(Properties, 0, len(values))
+	for _, name := range names {
+		p := &Property{
+			Name:     name,
+			PropType: PropertyString,
+			Value:    propertyValueString(values[name]),
+		}
+		*ps = append(*ps, p)
+	}
+	return nil
+}
+
+//Get returns the named property or nil if it does not exist
+func (ps Properties) Get(name string) *Property {
+	for _, p := range ps {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+//Has returns true if the named property exists
+func (ps Properties) Has(name string) bool {
+	return ps.Get(name) != nil
+}
+
+//GetString returns the named property as a string or "" if it does not exist
+func (ps Properties) GetString(name string) string {
+	if p := ps.Get(name); p != nil {
+		return p.String()
+	}
+	return ""
+}
+
+//GetInt returns the named property as an int or 0 if it does not exist
+func (ps Properties) GetInt(name string) int {
+	if p := ps.Get(name); p != nil {
+		return p.Int()
+	}
+	return 0
+}
+
+//GetFloat returns the named property as a float64 or 0 if it does not exist
+func (ps Properties) GetFloat(name string) float64 {
+	if p := ps.Get(name); p != nil {
+		return p.Float()
+	}
+	return 0
+}
+
+//GetBool returns the named property as a bool or false if it does not exist
+func (ps Properties) GetBool(name string) bool {
+	if p := ps.Get(name); p != nil {
+		return p.Bool()
+	}
+	return false
+}
+
+//GetColor returns the named color property or nil if it does not exist
+func (ps Properties) GetColor(name string) color.Color {
+	if p := ps.Get(name); p != nil {
+		return p.Color()
+	}
+	return nil
+}
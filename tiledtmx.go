@@ -0,0 +1,499 @@
+package tentsuyu
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+)
+
+//tmxMap is the XML layout of a Tiled .tmx map
+type tmxMap struct {
+	Version         string        `xml:"version,attr"`
+	TiledVersion    string        `xml:"tiledversion,attr"`
+	Orientation     string        `xml:"orientation,attr"`
+	RenderOrder     string        `xml:"renderorder,attr"`
+	Width           int           `xml:"width,attr"`
+	Height          int           `xml:"height,attr"`
+	TileWidth       int           `xml:"tilewidth,attr"`
+	TileHeight      int           `xml:"tileheight,attr"`
+	Infinite        int           `xml:"infinite,attr"`
+	BackgroundColor string        `xml:"backgroundcolor,attr"`
+	NextLayerID     int           `xml:"nextlayerid,attr"`
+	NextObjectID    int           `xml:"nextobjectid,attr"`
+	StaggerAxis     string        `xml:"staggeraxis,attr"`
+	StaggerIndex    string        `xml:"staggerindex,attr"`
+	HexSideLength   int           `xml:"hexsidelength,attr"`
+	ParallaxOriginX float64       `xml:"parallaxoriginx,attr"`
+	ParallaxOriginY float64       `xml:"parallaxoriginy,attr"`
+	Properties      tmxProperties `xml:"properties"`
+	TileSets        []*tmxTileSet `xml:"tileset"`
+	Layers          []*tmxLayer   `xml:",any"`
+}
+
+//tmxLayer is any of the layer elements of a .tmx map: layer, objectgroup, imagelayer or group
+type tmxLayer struct {
+	XMLName    xml.Name
+	ID         int           `xml:"id,attr"`
+	Name       string        `xml:"name,attr"`
+	X          int           `xml:"x,attr"`
+	Y          int           `xml:"y,attr"`
+	Width      int           `xml:"width,attr"`
+	Height     int           `xml:"height,attr"`
+	Opacity    *float64      `xml:"opacity,attr"`
+	Visible    *int          `xml:"visible,attr"`
+	OffsetX    float64       `xml:"offsetx,attr"`
+	OffsetY    float64       `xml:"offsety,attr"`
+	TintColor  string        `xml:"tintcolor,attr"`
+	ParallaxX  *float64      `xml:"parallaxx,attr"`
+	ParallaxY  *float64      `xml:"parallaxy,attr"`
+	RepeatX    int           `xml:"repeatx,attr"`
+	RepeatY    int           `xml:"repeaty,attr"`
+	DrawOrder  string        `xml:"draworder,attr"`
+	Properties tmxProperties `xml:"properties"`
+	Data       *tmxData      `xml:"data"`
+	Objects    []*tmxObject  `xml:"object"`
+	Image      *tmxImage     `xml:"image"`
+	Layers     []*tmxLayer   `xml:",any"`
+}
+
+//tmxData is the tile data of a .tmx layer
+type tmxData struct {
+	Encoding    string         `xml:"encoding,attr"`
+	Compression string         `xml:"compression,attr"`
+	Inner       string         `xml:",chardata"`
+	Tiles       []tmxDataTile  `xml:"tile"`
+	Chunks      []*tmxDataNode `xml:"chunk"`
+}
+
+//tmxDataNode is a chunk of the tile data of an infinite .tmx map
+type tmxDataNode struct {
+	X      int           `xml:"x,attr"`
+	Y      int           `xml:"y,attr"`
+	Width  int           `xml:"width,attr"`
+	Height int           `xml:"height,attr"`
+	Inner  string        `xml:",chardata"`
+	Tiles  []tmxDataTile `xml:"tile"`
+}
+
+type tmxDataTile struct {
+	Gid uint32 `xml:"gid,attr"`
+}
+
+type tmxImage struct {
+	Source string `xml:"source,attr"`
+	Width  int    `xml:"width,attr"`
+	Height int    `xml:"height,attr"`
+}
+
+//tmxTileSet is a tileset embedded in a .tmx map or the root of a .tsx file
+type tmxTileSet struct {
+	FirstGID   int           `xml:"firstgid,attr"`
+	Source     string        `xml:"source,attr"`
+	Name       string        `xml:"name,attr"`
+	TileWidth  int           `xml:"tilewidth,attr"`
+	TileHeight int           `xml:"tileheight,attr"`
+	Spacing    int           `xml:"spacing,attr"`
+	Margin     int           `xml:"margin,attr"`
+	TileCount  int           `xml:"tilecount,attr"`
+	Columns    int           `xml:"columns,attr"`
+	Image      tmxImage      `xml:"image"`
+	Properties tmxProperties `xml:"properties"`
+	Tiles      []*tmxTile    `xml:"tile"`
+}
+
+//tmxTile is the extra data of a single tile in a tileset
+type tmxTile struct {
+	ID          int           `xml:"id,attr"`
+	Type        string        `xml:"type,attr"`
+	Class       string        `xml:"class,attr"`
+	Probability float64       `xml:"probability,attr"`
+	Properties  tmxProperties `xml:"properties"`
+	Animation   []*TileFrame  `xml:"animation>frame"`
+	ObjectGroup *tmxLayer     `xml:"objectgroup"`
+}
+
+type tmxObject struct {
+	ID         int           `xml:"id,attr"`
+	Name       string        `xml:"name,attr"`
+	Type       string        `xml:"type,attr"`
+	X          float64       `xml:"x,attr"`
+	Y          float64       `xml:"y,attr"`
+	Width      float64       `xml:"width,attr"`
+	Height     float64       `xml:"height,attr"`
+	Rotation   float64       `xml:"rotation,attr"`
+	Gid        uint32        `xml:"gid,attr"`
+	Visible    *int          `xml:"visible,attr"`
+	Template   string        `xml:"template,attr"`
+	Properties tmxProperties `xml:"properties"`
+	Ellipse    *struct{}     `xml:"ellipse"`
+	Point      *struct{}     `xml:"point"`
+	Polygon    *tmxPoints    `xml:"polygon"`
+	Polyline   *tmxPoints    `xml:"polyline"`
+}
+
+type tmxPoints struct {
+	Points string `xml:"points,attr"`
+}
+
+type tmxProperties struct {
+	Property []tmxProperty `xml:"property"`
+}
+
+type tmxProperty struct {
+	Name  string `xml:"name,attr"`
+	Type  string `xml:"type,attr"`
+	Value string `xml:"value,attr"`
+	Inner string `xml:",chardata"`
+}
+
+//ReadTMX reads a Tiled .tmx map. External tilesets are left with only their Source and FirstGID set,
+//use ReadMapFS to have them loaded as well.
+func ReadTMX(r io.Reader) (*Map, error) {
+	tm := &tmxMap{}
+	if err := xml.NewDecoder(r).Decode(tm); err != nil {
+		return nil, err
+	}
+	m := &Map{
+		Version:         tm.Version,
+		TiledVersion:    tm.TiledVersion,
+		Orientation:     tm.Orientation,
+		RenderOrder:     tm.RenderOrder,
+		Width:           tm.Width,
+		Height:          tm.Height,
+		TileWidth:       tm.TileWidth,
+		TileHeight:      tm.TileHeight,
+		Infinite:        tm.Infinite == 1,
+		BackgroundColor: tm.BackgroundColor,
+		NextLayerID:     tm.NextLayerID,
+		NextObjectID:    tm.NextObjectID,
+		StaggerAxis:     tm.StaggerAxis,
+		StaggerIndex:    tm.StaggerIndex,
+		HexSideLength:   tm.HexSideLength,
+		ParallaxOriginX: tm.ParallaxOriginX,
+		ParallaxOriginY: tm.ParallaxOriginY,
+		Properties:      tm.Properties.convert(),
+	}
+	for _, ts := range tm.TileSets {
+		tileSet, err := ts.convert()
+		if err != nil {
+			return nil, err
+		}
+		m.TileSets = append(m.TileSets, tileSet)
+	}
+	layers, err := convertTMXLayers(tm.Layers)
+	if err != nil {
+		return nil, err
+	}
+	m.Layers = layers
+	return m, nil
+}
+
+//ReadTSX reads an external Tiled .tsx tileset
+func ReadTSX(r io.Reader) (*TileSet, error) {
+	ts := &tmxTileSet{}
+	if err := xml.NewDecoder(r).Decode(ts); err != nil {
+		return nil, err
+	}
+	return ts.convert()
+}
+
+//ReadMapFS reads a Tiled map in either the .tmx or .json format from the file system,
+//loading any external .tsx or .json tilesets it references relative to the map
+func ReadMapFS(fsys fs.FS, name string) (*Map, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var m *Map
+	if strings.EqualFold(path.Ext(name), ".tmx") {
+		if m, err = ReadTMX(f); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+	} else {
+		raw, err := ioutil.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		m = &Map{}
+		if err := unmarshalJSON(name, raw, m); err != nil {
+			return nil, err
+		}
+	}
+
+	dir := path.Dir(name)
+	for i, ts := range m.TileSets {
+		if ts.Source == "" {
+			continue
+		}
+		external, err := ReadTileSetFS(fsys, path.Join(dir, ts.Source))
+		if err != nil {
+			return nil, &MissingTileSetError{Map: name, Source: ts.Source, Err: err}
+		}
+		external.FirstGID = ts.FirstGID
+		external.Source = ts.Source
+		m.TileSets[i] = external
+	}
+	return m, nil
+}
+
+//ReadTileSetFS reads an external Tiled tileset in either the .tsx or .json format from the file system
+func ReadTileSetFS(fsys fs.FS, name string) (*TileSet, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(path.Ext(name), ".tsx") {
+		ts, err := ReadTSX(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		return ts, nil
+	}
+	raw, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	ts := &TileSet{}
+	if err := unmarshalJSON(name, raw, ts); err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+//CreateTileMapFS reads the map from the file system with ReadMapFS and creates a renderable TileMap
+func CreateTileMapFS(fsys fs.FS, name string) (*TileMap, error) {
+	m, err := ReadMapFS(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return CreateTileMap(m), nil
+}
+
+func (ts *tmxTileSet) convert() (*TileSet, error) {
+	tileSet := &TileSet{
+		FirstGID:    ts.FirstGID,
+		Source:      ts.Source,
+		Name:        ts.Name,
+		ImageName:   ts.Image.Source,
+		ImageWidth:  ts.Image.Width,
+		ImageHeight: ts.Image.Height,
+		TileWidth:   ts.TileWidth,
+		TileHeight:  ts.TileHeight,
+		Spacing:     ts.Spacing,
+		Margin:      ts.Margin,
+		TileCount:   ts.TileCount,
+		Columns:     ts.Columns,
+		Properties:  ts.Properties.convert(),
+	}
+	for _, t := range ts.Tiles {
+		tile, err := t.convert()
+		if err != nil {
+			return nil, fmt.Errorf("tileset %q: %v", ts.Name, err)
+		}
+		tileSet.Tiles = append(tileSet.Tiles, tile)
+	}
+	return tileSet, nil
+}
+
+func (t *tmxTile) convert() (*TileSetTile, error) {
+	tile := &TileSetTile{
+		ID:          t.ID,
+		Type:        t.Type,
+		Probability: t.Probability,
+		Properties:  t.Properties.convert(),
+		Animation:   t.Animation,
+	}
+	if tile.Type == "" {
+		tile.Type = t.Class
+	}
+	if t.ObjectGroup != nil {
+		t.ObjectGroup.XMLName.Local = "objectgroup"
+		layers, err := convertTMXLayers([]*tmxLayer{t.ObjectGroup})
+		if err != nil {
+			return nil, fmt.Errorf("tile %d: %v", t.ID, err)
+		}
+		tile.ObjectGroup = layers[0]
+	}
+	return tile, nil
+}
+
+func (ps tmxProperties) convert() Properties {
+	if len(ps.Property) == 0 {
+		return nil
+	}
+	props := make(Properties, 0, len(ps.Property))
+	for _, p := range ps.Property {
+		prop := &Property{
+			Name:     p.Name,
+			PropType: p.Type,
+			Value:    p.Value,
+		}
+		if prop.PropType == "" {
+			prop.PropType = PropertyString
+		}
+		//Multiline strings are stored as the element text
+		if prop.Value == "" && strings.TrimSpace(p.Inner) != "" {
+			prop.Value = p.Inner
+		}
+		props = append(props, prop)
+	}
+	return props
+}
+
+//convertTMXLayers converts .tmx layer elements into Layers keeping their order
+func convertTMXLayers(tmxLayers []*tmxLayer) ([]*Layer, error) {
+	layers := []*Layer{}
+	for _, tl := range tmxLayers {
+		layer := &Layer{
+			ID:         tl.ID,
+			Name:       tl.Name,
+			X:          tl.X,
+			Y:          tl.Y,
+			Width:      tl.Width,
+			Height:     tl.Height,
+			Opacity:    1,
+			Visible:    true,
+			OffsetX:    tl.OffsetX,
+			OffsetY:    tl.OffsetY,
+			TintColor:  tl.TintColor,
+			ParallaxX:  tl.ParallaxX,
+			ParallaxY:  tl.ParallaxY,
+			RepeatX:    tl.RepeatX != 0,
+			RepeatY:    tl.RepeatY != 0,
+			DrawOrder:  tl.DrawOrder,
+			Properties: tl.Properties.convert(),
+		}
+		if tl.Opacity != nil {
+			layer.Opacity = *tl.Opacity
+		}
+
+		if tl.Visible != nil {
+			layer.Visible = *tl.Visible != 0
+		}
+		switch tl.XMLName.Local {
+		case "layer":
+			layer.Type = "tilelayer"
+			if tl.Data != nil {
+				var err error
+				if layer.Data, err = decodeTMXData(tl.Data.Inner, tl.Data.Tiles, tl.Data.Encoding, tl.Data.Compression); err != nil {
+					return nil, fmt.Errorf("layer %q: %v", tl.Name, err)
+				}
+				for _, c := range tl.Data.Chunks {
+					chunk := &Chunk{
+						X:      c.X,
+						Y:      c.Y,
+						Width:  c.Width,
+						Height: c.Height,
+					}
+					if chunk.Data, err = decodeTMXData(c.Inner, c.Tiles, tl.Data.Encoding, tl.Data.Compression); err != nil {
+						return nil, fmt.Errorf("layer %q chunk %d,%d: %v", tl.Name, c.X, c.Y, err)
+					}
+					layer.Chunks = append(layer.Chunks, chunk)
+				}
+			}
+		case "objectgroup":
+			layer.Type = "objectgroup"
+			for _, o := range tl.Objects {
+				obj, err := o.convert()
+				if err != nil {
+					return nil, fmt.Errorf("layer %q: %v", tl.Name, err)
+				}
+				layer.Objects = append(layer.Objects, obj)
+			}
+		case "imagelayer":
+			layer.Type = "imagelayer"
+			if tl.Image != nil {
+				layer.Image = tl.Image.Source
+			}
+		case "group":
+			layer.Type = "group"
+			children, err := convertTMXLayers(tl.Layers)
+			if err != nil {
+				return nil, err
+			}
+			layer.Layers = children
+		default:
+			continue
+		}
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
+
+//decodeTMXData converts the tile data of a .tmx layer or chunk into raw GIDs
+func decodeTMXData(inner string, tiles []tmxDataTile, encoding, compression string) ([]int, error) {
+	switch encoding {
+	case "":
+		data := make([]int, len(tiles))
+		for i, t := range tiles {
+			data[i] = int(t.Gid)
+		}
+		return data, nil
+	case "csv":
+		return decodeCSVData(inner)
+	case "base64":
+		return decodeBase64Data(inner, compression)
+	}
+	return nil, fmt.Errorf("unsupported encoding %q", encoding)
+}
+
+func (o *tmxObject) convert() (*MapObject, error) {
+	obj := &MapObject{
+		ID:         o.ID,
+		Name:       o.Name,
+		Type:       o.Type,
+		X:          o.X,
+		Y:          o.Y,
+		Width:      o.Width,
+		Height:     o.Height,
+		Rotation:   o.Rotation,
+		Gid:        o.Gid,
+		Visible:    o.Visible == nil || *o.Visible != 0,
+		Template:   o.Template,
+		Ellipse:    o.Ellipse != nil,
+		Point:      o.Point != nil,
+		Properties: o.Properties.convert(),
+	}
+	var err error
+	if o.Polygon != nil {
+		if obj.Polygon, err = parseTMXPoints(o.Polygon.Points); err != nil {
+			return nil, err
+		}
+	}
+	if o.Polyline != nil {
+		if obj.Polyline, err = parseTMXPoints(o.Polyline.Points); err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
+
+//parseTMXPoints parses the "x1,y1 x2,y2" points of a polygon or polyline
+func parseTMXPoints(s string) ([]Point, error) {
+	points := []Point{}
+	for _, pair := range strings.Fields(s) {
+		xy := strings.Split(pair, ",")
+		if len(xy) != 2 {
+			return nil, fmt.Errorf("invalid point %q", pair)
+		}
+		x, err := strconv.ParseFloat(xy[0], 64)
+		if err != nil {
+			return nil, err
+		}
+		y, err := strconv.ParseFloat(xy[1], 64)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, Point{X: x, Y: y})
+	}
+	return points, nil
+}
@@ -0,0 +1,358 @@
+package tentsuyu
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+//exportChunkSize is the size in tiles of the chunks written for infinite maps, the same as Tiled uses
+const exportChunkSize = 16
+
+//Layer returns the first layer with the given name or nil if there is none
+func (tm *TileMap) Layer(name string) *TileLayer {
+	if i := tm.LayerIndex(name); i >= 0 {
+		return tm.Layers[i]
+	}
+	return nil
+}
+
+//LayerIndex returns the index of the first layer with the given name or -1 if there is none
+func (tm *TileMap) LayerIndex(name string) int {
+	for i, l := range tm.Layers {
+		if l.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+//tileLayer returns the tile layer at the index or an error if it is not one
+func (tm *TileMap) tileLayer(layer int) (*TileLayer, error) {
+	if layer < 0 || layer >= len(tm.Layers) {
+		return nil, fmt.Errorf("layer %d out of range", layer)
+	}
+	tl := tm.Layers[layer]
+	if tl.IsImageLayer || tl.IsObjectLayer {
+		return nil, fmt.Errorf("layer %q is not a tile layer", tl.Name)
+	}
+	return tl, nil
+}
+
+//GetTile returns the Tile at the column and row of the layer, nil if the cell is empty
+func (tm *TileMap) GetTile(layer, col, row int) *Tile {
+	if layer < 0 || layer >= len(tm.Layers) {
+		return nil
+	}
+	return tm.Layers[layer].TileAt(col, row)
+}
+
+//SetTile places the tile with the raw Tiled GID, which may include flip flags, at the column and row of the layer.
+//A GID of 0 clears the cell. Infinite maps grow the layer to fit the cell. OnTileChange is called after the change.
+func (tm *TileMap) SetTile(layer, col, row int, gid uint32) (*Tile, error) {
+	tl, err := tm.tileLayer(layer)
+	if err != nil {
+		return nil, err
+	}
+	if gid == 0 {
+		return nil, tm.ClearTile(layer, col, row)
+	}
+	if tl.gridIndex(col, row) < 0 {
+		if !tm.Infinite {
+			return nil, fmt.Errorf("cell %d,%d is outside layer %q", col, row, tl.Name)
+		}
+		tl.growGrid(col, row)
+	}
+	t := tm.newGridTile(gid, col, row, tl.OffsetX, tl.OffsetY)
+	if t.BasicImageParts == nil {
+		return nil, fmt.Errorf("gid %d is not in any TileSet", t.Gid)
+	}
+	tl.applyLayerCollision(t)
+
+	if old := tl.TileAt(col, row); old != nil {
+		tl.Data[tl.dataIndex(old)] = t
+	} else {
+		tl.Data = append(tl.Data, t)
+	}
+	tl.setGridTile(col, row, t)
+	if t.Animation != nil && tl.Static {
+		tl.Static = false
+		tl.InvalidateCache()
+	}
+	tl.InvalidateTile(col, row)
+	if tm.OnTileChange != nil {
+		tm.OnTileChange(layer, col, row)
+	}
+	return t, nil
+}
+
+//ClearTile removes the tile at the column and row of the layer
+func (tm *TileMap) ClearTile(layer, col, row int) error {
+	tl, err := tm.tileLayer(layer)
+	if err != nil {
+		return err
+	}
+	old := tl.TileAt(col, row)
+	if old == nil {
+		return nil
+	}
+	i := tl.dataIndex(old)
+	tl.Data = append(tl.Data[:i], tl.Data[i+1:]...)
+	tl.grid[tl.gridIndex(col, row)] = nil
+	tl.InvalidateTile(col, row)
+	if tm.OnTileChange != nil {
+		tm.OnTileChange(layer, col, row)
+	}
+	return nil
+}
+
+//dataIndex returns the index of the tile in Data
+func (tl *TileLayer) dataIndex(t *Tile) int {
+	for i, d := range tl.Data {
+		if d == t {
+			return i
+		}
+	}
+	return -1
+}
+
+//growGrid enlarges the grid of the layer to include the column and row
+func (tl *TileLayer) growGrid(col, row int) {
+	x0, y0 := minInt(tl.GridX, col), minInt(tl.GridY, row)
+	x1, y1 := maxInt(tl.GridX+tl.Width, col+1), maxInt(tl.GridY+tl.Height, row+1)
+	if tl.grid == nil {
+		x0, y0, x1, y1 = col, row, col+1, row+1
+	}
+	grid := make([]*Tile, (x1-x0)*(y1-y0))
+	for r := 0; r < tl.Height; r++ {
+		for c := 0; c < tl.Width; c++ {
+			grid[(tl.GridY+r-y0)*(x1-x0)+tl.GridX+c-x0] = tl.grid[r*tl.Width+c]
+		}
+	}
+	tl.grid = grid
+	tl.GridX, tl.GridY = x0, y0
+	tl.Width, tl.Height = x1-x0, y1-y0
+	//The chunks no longer line up with the grid
+	tl.InvalidateCache()
+	tl.chunks = nil
+}
+
+//AddTileLayer adds an empty tile layer the size of the map on top of the other layers
+func (tm *TileMap) AddTileLayer(name string) *TileLayer {
+	tl := &TileLayer{
+		ID:              tm.nextLayerID(),
+		Name:            name,
+		Data:            []*Tile{},
+		Opacity:         1,
+		ParallaxX:       1,
+		ParallaxY:       1,
+		TileWidth:       tm.TileWidth,
+		TileHeight:      tm.TileHeight,
+		Static:          true,
+		layout:          tm.tileLayout(),
+		parallaxOriginX: tm.ParallaxOriginX,
+		parallaxOriginY: tm.ParallaxOriginY,
+	}
+	tl.initGrid(&Layer{Width: tm.Width, Height: tm.Height})
+	tm.Layers = append(tm.Layers, tl)
+	return tl
+}
+
+//InsertLayer adds the layer at the index, moving the layers above it up by one
+func (tm *TileMap) InsertLayer(index int, tl *TileLayer) error {
+	if index < 0 || index > len(tm.Layers) {
+		return fmt.Errorf("layer %d out of range", index)
+	}
+	if tl.ID == 0 {
+		tl.ID = tm.nextLayerID()
+	}
+	if tl.layout == nil {
+		tl.layout = tm.tileLayout()
+	}
+	tm.Layers = append(tm.Layers, nil)
+	copy(tm.Layers[index+1:], tm.Layers[index:])
+	tm.Layers[index] = tl
+	return nil
+}
+
+//RemoveLayer removes and returns the layer at the index, disposing of its cached chunks
+func (tm *TileMap) RemoveLayer(index int) (*TileLayer, error) {
+	if index < 0 || index >= len(tm.Layers) {
+		return nil, fmt.Errorf("layer %d out of range", index)
+	}
+	tl := tm.Layers[index]
+	tm.Layers = append(tm.Layers[:index], tm.Layers[index+1:]...)
+	tl.InvalidateCache()
+	return tl, nil
+}
+
+//nextLayerID returns an ID higher than the ID of every layer
+func (tm *TileMap) nextLayerID() int {
+	id := 1
+	for _, l := range tm.Layers {
+		if l.ID >= id {
+			id = l.ID + 1
+		}
+	}
+	return id
+}
+
+//ToMap converts the TileMap back into a Tiled Map. Group layers were flattened by CreateTileMap
+//so every layer is written at the top level with the offset and opacity of its groups applied.
+func (tm *TileMap) ToMap() *Map {
+	m := &Map{
+		Width:           tm.Width,
+		Height:          tm.Height,
+		TileWidth:       tm.TileWidth,
+		TileHeight:      tm.TileHeight,
+		Orientation:     tm.Orientation,
+		StaggerAxis:     tm.StaggerAxis,
+		StaggerIndex:    tm.StaggerIndex,
+		HexSideLength:   tm.HexSideLength,
+		ParallaxOriginX: tm.ParallaxOriginX,
+		ParallaxOriginY: tm.ParallaxOriginY,
+		RenderOrder:     tm.RenderOrder,
+		Infinite:        tm.Infinite,
+		Properties:      tm.Properties,
+		TileSets:        tm.TileSets,
+		Version:         "1.4",
+		Layers:          []*Layer{},
+		NextObjectID:    1,
+	}
+	if m.Orientation == "" {
+		m.Orientation = "orthogonal"
+	}
+	if m.RenderOrder == "" {
+		m.RenderOrder = "right-down"
+	}
+	m.BackgroundColor = tiledColorString(tm.BackgroundColor)
+	nextID := tm.nextLayerID()
+	for _, tl := range tm.Layers {
+		layer := tl.toLayer(tm.Infinite)
+		if layer.ID == 0 {
+			layer.ID = nextID
+			nextID++
+		}
+		for _, o := range layer.Objects {
+			if o.ID >= m.NextObjectID {
+				m.NextObjectID = o.ID + 1
+			}
+		}
+		m.Layers = append(m.Layers, layer)
+	}
+	m.NextLayerID = nextID
+	return m
+}
+
+//toLayer converts the TileLayer into a Tiled Layer
+func (tl *TileLayer) toLayer(infinite bool) *Layer {
+	layer := &Layer{
+		ID:         tl.ID,
+		Name:       tl.Name,
+		Opacity:    tl.Opacity,
+		Visible:    !tl.Hidden,
+		OffsetX:    tl.OffsetX,
+		OffsetY:    tl.OffsetY,
+		TintColor:  tiledColorString(tl.Tint),
+		Properties: tl.Properties,
+	}
+	layer.SetParallax(tl.parallax())
+	if layer.Opacity == 0 {
+		layer.Opacity = 1
+	}
+	switch {
+	case tl.IsImageLayer:
+		layer.Type = "imagelayer"
+		layer.Image = tl.Image
+		layer.X, layer.Y = int(tl.X), int(tl.Y)
+		layer.RepeatX, layer.RepeatY = tl.RepeatX, tl.RepeatY
+	case tl.IsObjectLayer:
+		layer.Type = "objectgroup"
+		layer.DrawOrder = "topdown"
+		layer.Objects = tl.Objects
+		if layer.Objects == nil {
+			layer.Objects = []*MapObject{}
+		}
+	default:
+		layer.Type = "tilelayer"
+		layer.Width, layer.Height = tl.Width, tl.Height
+		if infinite {
+			layer.StartX, layer.StartY = tl.GridX, tl.GridY
+			layer.Chunks = tl.exportChunks()
+		} else {
+			layer.X, layer.Y = tl.GridX, tl.GridY
+			layer.Data = tl.exportData(tl.GridX, tl.GridY, tl.Width, tl.Height)
+		}
+	}
+	return layer
+}
+
+//exportData returns the raw GIDs of the block of cells starting at col, row
+func (tl *TileLayer) exportData(col, row, width, height int) []int {
+	data := make([]int, width*height)
+	for r := 0; r < height; r++ {
+		for c := 0; c < width; c++ {
+			if t := tl.TileAt(col+c, row+r); t != nil {
+				data[r*width+c] = int(EncodeGID(t.Gid, t.FlipH, t.FlipV, t.FlipD))
+			}
+		}
+	}
+	return data
+}
+
+//exportChunks splits the layer into chunks aligned to exportChunkSize, leaving out empty chunks
+func (tl *TileLayer) exportChunks() []*Chunk {
+	chunks := []*Chunk{}
+	if tl.Width <= 0 || tl.Height <= 0 {
+		return chunks
+	}
+	n := exportChunkSize
+	startX := floorDiv(tl.GridX, n) * n
+	startY := floorDiv(tl.GridY, n) * n
+	for y := startY; y < tl.GridY+tl.Height; y += n {
+		for x := startX; x < tl.GridX+tl.Width; x += n {
+			data := tl.exportData(x, y, n, n)
+			empty := true
+			for _, gid := range data {
+				if gid != 0 {
+					empty = false
+					break
+				}
+			}
+			if !empty {
+				chunks = append(chunks, &Chunk{X: x, Y: y, Width: n, Height: n, Data: data})
+			}
+		}
+	}
+	return chunks
+}
+
+//floorDiv divides rounding towards negative infinity
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+//WriteJSON writes the TileMap as a Tiled JSON map
+func (tm *TileMap) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	return enc.Encode(tm.ToMap())
+}
+
+//SaveJSON writes the TileMap to a Tiled JSON file
+func (tm *TileMap) SaveJSON(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := tm.WriteJSON(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
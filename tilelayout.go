@@ -0,0 +1,318 @@
+package tentsuyu
+
+import "math"
+
+//Tiled map orientations
+const (
+	OrientationOrthogonal = "orthogonal"
+	OrientationIsometric  = "isometric"
+	OrientationStaggered  = "staggered"
+	OrientationHexagonal  = "hexagonal"
+)
+
+//tileLayout converts between tile and world coordinates for the orientation of a map.
+//It follows the layout Tiled uses so maps look the same in the game as in the editor.
+type tileLayout struct {
+	orientation           string
+	tileWidth, tileHeight int
+	staggerX, staggerEven bool
+	sideLengthX           float64
+	sideLengthY           float64
+	sideOffsetX           float64
+	sideOffsetY           float64
+	columnWidth           float64
+	rowHeight             float64
+	originX               float64
+}
+
+//newTileLayout creates the layout of the TileMap from its orientation settings
+func newTileLayout(tm *TileMap) *tileLayout {
+	l := &tileLayout{
+		orientation: tm.Orientation,
+		tileWidth:   tm.TileWidth,
+		tileHeight:  tm.TileHeight,
+		staggerX:    tm.StaggerAxis == "x",
+		staggerEven: tm.StaggerIndex == "even",
+	}
+	tw, th := float64(tm.TileWidth), float64(tm.TileHeight)
+	switch l.orientation {
+	case OrientationIsometric:
+		l.originX = float64(tm.Height) * tw / 2
+	case OrientationStaggered, OrientationHexagonal:
+		//A staggered map is laid out as a hexagonal map with sides of length 0
+		side := 0.0
+		if l.orientation == OrientationHexagonal {
+			side = float64(tm.HexSideLength)
+		}
+		if l.staggerX {
+			l.sideLengthX = side
+		} else {
+			l.sideLengthY = side
+		}
+		l.sideOffsetX = (tw - l.sideLengthX) / 2
+		l.sideOffsetY = (th - l.sideLengthY) / 2
+		l.columnWidth = l.sideOffsetX + l.sideLengthX
+		l.rowHeight = l.sideOffsetY + l.sideLengthY
+	}
+	return l
+}
+
+//orthogonal returns true if the layout is a plain grid
+func (l *tileLayout) orthogonal() bool {
+	return l == nil || (l.orientation != OrientationIsometric && l.orientation != OrientationStaggered && l.orientation != OrientationHexagonal)
+}
+
+//doStaggerX returns true if the column is shifted down
+func (l *tileLayout) doStaggerX(col int) bool {
+	return l.staggerX && (col&1 != 0) != l.staggerEven
+}
+
+//doStaggerY returns true if the row is shifted right
+func (l *tileLayout) doStaggerY(row int) bool {
+	return !l.staggerX && (row&1 != 0) != l.staggerEven
+}
+
+//tileToWorld returns the top left corner of the bounding box of the cell
+func (l *tileLayout) tileToWorld(col, row int) (float64, float64) {
+	tw, th := float64(l.tileWidth), float64(l.tileHeight)
+	switch {
+	case l.orthogonal():
+		return float64(col) * tw, float64(row) * th
+	case l.orientation == OrientationIsometric:
+		return float64(col-row)*tw/2 + l.originX - tw/2, float64(col+row) * th / 2
+	case l.staggerX:
+		y := float64(row) * (th + l.sideLengthY)
+		if l.doStaggerX(col) {
+			y += l.rowHeight
+		}
+		return float64(col) * l.columnWidth, y
+	default:
+		x := float64(col) * (tw + l.sideLengthX)
+		if l.doStaggerY(row) {
+			x += l.columnWidth
+		}
+		return x, float64(row) * l.rowHeight
+	}
+}
+
+//worldToTile returns the cell containing the world point
+func (l *tileLayout) worldToTile(x, y float64) (int, int) {
+	tw, th := float64(l.tileWidth), float64(l.tileHeight)
+	switch {
+	case l.orthogonal():
+		return int(math.Floor(x / tw)), int(math.Floor(y / th))
+	case l.orientation == OrientationIsometric:
+		x -= l.originX
+		tx, ty := x/tw, y/th
+		return int(math.Floor(ty + tx)), int(math.Floor(ty - tx))
+	case l.orientation == OrientationStaggered:
+		return l.staggeredWorldToTile(x, y)
+	default:
+		return l.hexagonalWorldToTile(x, y)
+	}
+}
+
+//staggeredWorldToTile finds the diamond containing the point by checking the corners of its bounding box
+func (l *tileLayout) staggeredWorldToTile(x, y float64) (int, int) {
+	tw, th := float64(l.tileWidth), float64(l.tileHeight)
+	if l.staggerX {
+		if l.staggerEven {
+			x -= l.sideOffsetX
+		}
+	} else if l.staggerEven {
+		y -= l.sideOffsetY
+	}
+	refX, refY := int(math.Floor(x/tw)), int(math.Floor(y/th))
+	relX, relY := x-float64(refX)*tw, y-float64(refY)*th
+	if l.staggerX {
+		refX *= 2
+		if l.staggerEven {
+			refX++
+		}
+	} else {
+		refY *= 2
+		if l.staggerEven {
+			refY++
+		}
+	}
+	yPos := relX * (th / tw)
+	switch {
+	case l.sideOffsetY-yPos > relY:
+		return l.staggeredNeighbour(refX, refY, -1, -1)
+	case -l.sideOffsetY+yPos > relY:
+		return l.staggeredNeighbour(refX, refY, 1, -1)
+	case l.sideOffsetY+yPos < relY:
+		return l.staggeredNeighbour(refX, refY, -1, 1)
+	case l.sideOffsetY*3-yPos < relY:
+		return l.staggeredNeighbour(refX, refY, 1, 1)
+	}
+	return refX, refY
+}
+
+//staggeredNeighbour returns the diagonal neighbour of the cell in direction dx, dy of a staggered map
+func (l *tileLayout) staggeredNeighbour(col, row, dx, dy int) (int, int) {
+	if l.staggerX {
+		//The column always changes, the row only changes when moving away from the shift of the column
+		shifted := l.doStaggerX(col)
+		if (dy < 0 && shifted) || (dy > 0 && !shifted) {
+			return col + dx, row
+		}
+		return col + dx, row + dy
+	}
+	//The row always changes, the column only changes when moving away from the shift of the row
+	shifted := l.doStaggerY(row)
+	if (dx < 0 && shifted) || (dx > 0 && !shifted) {
+		return col, row + dy
+	}
+	return col + dx, row + dy
+}
+
+//hexagonalWorldToTile finds the hexagon whose center is closest to the point
+func (l *tileLayout) hexagonalWorldToTile(x, y float64) (int, int) {
+	tw, th := float64(l.tileWidth), float64(l.tileHeight)
+	if l.staggerX {
+		if l.staggerEven {
+			x -= tw
+		} else {
+			x -= l.sideOffsetX
+		}
+	} else {
+		if l.staggerEven {
+			y -= th
+		} else {
+			y -= l.sideOffsetY
+		}
+	}
+	refX := int(math.Floor(x / (l.columnWidth * 2)))
+	refY := int(math.Floor(y / (l.rowHeight * 2)))
+	relX := x - float64(refX)*l.columnWidth*2
+	relY := y - float64(refY)*l.rowHeight*2
+	if l.staggerX {
+		refX *= 2
+		if l.staggerEven {
+			refX++
+		}
+	} else {
+		refY *= 2
+		if l.staggerEven {
+			refY++
+		}
+	}
+
+	var centers [4][2]float64
+	var offsets [4][2]int
+	if l.staggerX {
+		left := l.sideLengthX / 2
+		centerX := left + l.columnWidth
+		centerY := th / 2
+		centers = [4][2]float64{{left, centerY}, {centerX, 0}, {centerX, th}, {centerX + l.columnWidth, centerY}}
+		offsets = [4][2]int{{0, 0}, {1, -1}, {1, 0}, {2, 0}}
+	} else {
+		top := l.sideLengthY / 2
+		centerX := tw / 2
+		centerY := top + l.rowHeight
+		centers = [4][2]float64{{centerX, top}, {0, centerY}, {tw, centerY}, {centerX, centerY + l.rowHeight}}
+		offsets = [4][2]int{{0, 0}, {-1, 1}, {0, 1}, {0, 2}}
+	}
+	nearest := 0
+	minDist := math.MaxFloat64
+	for i, c := range centers {
+		d := (c[0]-relX)*(c[0]-relX) + (c[1]-relY)*(c[1]-relY)
+		if d < minDist {
+			minDist = d
+			nearest = i
+		}
+	}
+	return refX + offsets[nearest][0], refY + offsets[nearest][1]
+}
+
+//objectToWorld converts the position of a Tiled object into world coordinates.
+//Objects on isometric maps are stored in tile units along the two axes of the map.
+func (l *tileLayout) objectToWorld(x, y float64) (float64, float64) {
+	if l == nil || l.orientation != OrientationIsometric {
+		return x, y
+	}
+	tw, th := float64(l.tileWidth), float64(l.tileHeight)
+	tx, ty := x/th, y/th
+	return (tx-ty)*tw/2 + l.originX, (tx + ty) * th / 2
+}
+
+//drawOrder calls fn for the columns of a row in the order they have to be drawn.
+//Shifted columns of a map staggered along x overlap the columns beside them so they are drawn last.
+func (l *tileLayout) drawOrder(col0, col1 int, fn func(col int)) {
+	if l.orthogonal() || !l.staggerX {
+		for col := col0; col < col1; col++ {
+			fn(col)
+		}
+		return
+	}
+	for col := col0; col < col1; col++ {
+		if !l.doStaggerX(col) {
+			fn(col)
+		}
+	}
+	for col := col0; col < col1; col++ {
+		if l.doStaggerX(col) {
+			fn(col)
+		}
+	}
+}
+
+//TileToWorld returns the top left corner of the cell at the column and row in world coordinates
+func (tm *TileMap) TileToWorld(col, row int) (float64, float64) {
+	return tm.tileLayout().tileToWorld(col, row)
+}
+
+//TileCenter returns the center of the cell at the column and row in world coordinates
+func (tm *TileMap) TileCenter(col, row int) (float64, float64) {
+	x, y := tm.TileToWorld(col, row)
+	return x + float64(tm.TileWidth)/2, y + float64(tm.TileHeight)/2
+}
+
+//WorldToTile returns the column and row of the cell containing the world point
+func (tm *TileMap) WorldToTile(x, y float64) (col, row int) {
+	return tm.tileLayout().worldToTile(x, y)
+}
+
+//ScreenToTile returns the column and row of the cell under the screen point, such as the mouse, seen through the camera
+func (tm *TileMap) ScreenToTile(camera *Camera, x, y float64) (col, row int) {
+	return tm.WorldToTile(camera.ScreenToWorld(x, y))
+}
+
+//TileToScreen returns the top left corner of the cell on the screen when seen through the camera
+func (tm *TileMap) TileToScreen(camera *Camera, col, row int) (float64, float64) {
+	return camera.GetScreenCoords(tm.TileToWorld(col, row))
+}
+
+//PixelSize returns the size of the whole map in world coordinates
+func (tm *TileMap) PixelSize() (float64, float64) {
+	l := tm.tileLayout()
+	tw, th := float64(tm.TileWidth), float64(tm.TileHeight)
+	w, h := float64(tm.Width), float64(tm.Height)
+	switch {
+	case l.orthogonal():
+		return w * tw, h * th
+	case l.orientation == OrientationIsometric:
+		return (w + h) * tw / 2, (w + h) * th / 2
+	case l.staggerX:
+		height := h * (th + l.sideLengthY)
+		if tm.Width > 1 {
+			height += l.rowHeight
+		}
+		return w*l.columnWidth + l.sideOffsetX, height
+	default:
+		width := w * (tw + l.sideLengthX)
+		if tm.Height > 1 {
+			width += l.columnWidth
+		}
+		return width, h*l.rowHeight + l.sideOffsetY
+	}
+}
+
+//tileLayout returns the layout of the map, creating it when the map was not made by CreateTileMap
+func (tm *TileMap) tileLayout() *tileLayout {
+	if tm.layout == nil {
+		tm.layout = newTileLayout(tm)
+	}
+	return tm.layout
+}
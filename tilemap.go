@@ -2,74 +2,130 @@ package tentsuyu
 
 import (
 	"encoding/json"
-	"io/ioutil"
-	"log"
+	"image/color"
+	"io/fs"
 	"math"
 	"os"
+	"path/filepath"
 
 	"github.com/hajimehoshi/ebiten"
 )
 
 //Map represents an entire Tiled map
 type Map struct {
-	Width       int         `json:"width"`
-	Height      int         `json:"height"`
-	Layers      []*Layer    `json:"layers"`
-	Orientation string      `json:"orientation"`
-	TileWidth   int         `json:"tilewidth"`
-	TileHeight  int         `json:"tileheight"`
-	TileSets    []*TileSet  `json:"tilesets"`
-	Properties  []*Property `json:"properties"`
-	Version     int         `json:"version"`
+	Width           int        `json:"width"`
+	Height          int        `json:"height"`
+	Layers          []*Layer   `json:"layers"`
+	Orientation     string     `json:"orientation"`
+	RenderOrder     string     `json:"renderorder"`
+	TileWidth       int        `json:"tilewidth"`
+	TileHeight      int        `json:"tileheight"`
+	TileSets        []*TileSet `json:"tilesets"`
+	Properties      Properties `json:"properties,omitempty"`
+	Version         string     `json:"version"`
+	TiledVersion    string     `json:"tiledversion"`
+	Infinite        bool       `json:"infinite"`
+	BackgroundColor string     `json:"backgroundcolor,omitempty"`
+	NextLayerID     int        `json:"nextlayerid"`
+	NextObjectID    int        `json:"nextobjectid"`
+	StaggerAxis     string     `json:"staggeraxis,omitempty"`
+	StaggerIndex    string     `json:"staggerindex,omitempty"`
+	HexSideLength   int        `json:"hexsidelength,omitempty"`
+	ParallaxOriginX float64    `json:"parallaxoriginx,omitempty"`
+	ParallaxOriginY float64    `json:"parallaxoriginy,omitempty"`
 }
 
 //Layer represents a Tiled Layer
 type Layer struct {
-	Name       string       `json:"name"`
-	Type       string       `json:"type"`
-	X          int          `json:"x"`
-	Y          int          `json:"y"`
-	Width      int          `json:"width"`
-	Height     int          `json:"height"`
-	Data       []int        `json:"data"`
-	Opacity    int          `json:"opacity"`
-	Visible    bool         `json:"visible"`
-	Properties []*Property  `json:"properties"`
-	DrawOrder  string       `json:"draworder"`
-	Objects    []*MapObject `json:"objects"`
-	//Encoding   string       `json:"encoding"`
-	//	ImageName  string               `json:"image"`
+	ID          int          `json:"id"`
+	Name        string       `json:"name"`
+	Type        string       `json:"type"`
+	X           int          `json:"x"`
+	Y           int          `json:"y"`
+	Width       int          `json:"width"`
+	Height      int          `json:"height"`
+	Data        []int        `json:"data,omitempty"`
+	Opacity     float64      `json:"opacity"`
+	Visible     bool         `json:"visible"`
+	OffsetX     float64      `json:"offsetx"`
+	OffsetY     float64      `json:"offsety"`
+	Properties  Properties   `json:"properties,omitempty"`
+	DrawOrder   string       `json:"draworder,omitempty"`
+	Objects     []*MapObject `json:"objects,omitempty"`
+	Encoding    string       `json:"encoding,omitempty"`
+	Compression string       `json:"compression,omitempty"`
+	Chunks      []*Chunk     `json:"chunks,omitempty"`
+	StartX      int          `json:"startx,omitempty"`
+	StartY      int          `json:"starty,omitempty"`
+	Layers      []*Layer     `json:"layers,omitempty"`
+	Image       string       `json:"image,omitempty"`
+	TintColor   string       `json:"tintcolor,omitempty"`
+	ParallaxX   *float64     `json:"parallaxx,omitempty"`
+	ParallaxY   *float64     `json:"parallaxy,omitempty"`
+	RepeatX     bool         `json:"repeatx,omitempty"`
+	RepeatY     bool         `json:"repeaty,omitempty"`
+}
+
+//Chunk is a section of a tile layer in an infinite Tiled map.
+//X and Y are in tiles and Data holds the raw GIDs of the chunk.
+type Chunk struct {
+	X       int             `json:"x"`
+	Y       int             `json:"y"`
+	Width   int             `json:"width"`
+	Height  int             `json:"height"`
+	RawData json.RawMessage `json:"data"`
+	Data    []int           `json:"-"`
 }
 
 //TileSet represents a Tiled TileSet
 type TileSet struct {
-	FirstGID      int         `json:"firstgid"`
-	ImageName     string      `json:"image"`
-	ImageWidth    int         `json:"imagewidth"`
-	ImageHeight   int         `json:"imageheight"`
-	Margin        int         `json:"margin"`
-	Name          string      `json:"name"`
-	Properties    []*Property `json:"properties"`
-	Spacing       int         `json:"spacing"`
-	TileWidth     int         `json:"tilewidth"`
-	TileHeight    int         `json:"tileheight"`
-	Columns       int         `json:"columns"`
-	TileCount     int         `json:"tilecount"`
-	Image         *ebiten.Image
-	Rows, LastGID int
+	FirstGID      int            `json:"firstgid"`
+	Source        string         `json:"source,omitempty"`
+	ImageName     string         `json:"image,omitempty"`
+	ImageWidth    int            `json:"imagewidth"`
+	ImageHeight   int            `json:"imageheight"`
+	Margin        int            `json:"margin"`
+	Name          string         `json:"name"`
+	Properties    Properties     `json:"properties,omitempty"`
+	Spacing       int            `json:"spacing"`
+	TileWidth     int            `json:"tilewidth"`
+	TileHeight    int            `json:"tileheight"`
+	Columns       int            `json:"columns"`
+	TileCount     int            `json:"tilecount"`
+	Tiles         []*TileSetTile `json:"tiles"`
+	Image         *ebiten.Image  `json:"-"`
+	Rows, LastGID int            `json:"-"`
+}
+
+//TileSetTile holds the extra data Tiled stores for a single tile of a TileSet.
+//ID is the local id of the tile within the TileSet.
+type TileSetTile struct {
+	ID          int          `json:"id"`
+	Type        string       `json:"type"`
+	Probability float64      `json:"probability,omitempty"`
+	Properties  Properties   `json:"properties,omitempty"`
+	Animation   []*TileFrame `json:"animation,omitempty"`
+	ObjectGroup *Layer       `json:"objectgroup,omitempty"`
 }
 
 //MapObject is a representation of the Tiled Object
 type MapObject struct {
-	Height   int     `json:"height"`
-	ID       int     `json:"id"`
-	Name     string  `json:"name"`
-	Rotation float64 `json:"rotation"`
-	Type     string  `json:"type"`
-	Visible  bool    `json:"visible"`
-	Width    int     `json:"width"`
-	X        float64 `json:"x"`
-	Y        float64 `json:"y"`
+	Height     float64    `json:"height"`
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Rotation   float64    `json:"rotation"`
+	Type       string     `json:"type"`
+	Visible    bool       `json:"visible"`
+	Width      float64    `json:"width"`
+	X          float64    `json:"x"`
+	Y          float64    `json:"y"`
+	Gid        uint32     `json:"gid,omitempty"`
+	Ellipse    bool       `json:"ellipse,omitempty"`
+	Point      bool       `json:"point,omitempty"`
+	Polygon    []Point    `json:"polygon,omitempty"`
+	Polyline   []Point    `json:"polyline,omitempty"`
+	Template   string     `json:"template,omitempty"`
+	Properties Properties `json:"properties,omitempty"`
 }
 
 //Property of the layer/tile from Tiled
@@ -81,10 +137,19 @@ type Property struct {
 
 //Tile is a renderable tile used by the game
 type Tile struct {
-	Image     *ebiten.Image
-	Collide   bool
-	Gid       int
-	ImageName string
+	Image               *ebiten.Image
+	Collide             bool
+	Gid                 int
+	ImageName           string
+	FlipH, FlipV, FlipD bool
+	OneWay              bool
+	SlopeLeft           float64
+	SlopeRight          float64
+	Type                string
+	Properties          Properties
+	TileData            *TileSetTile
+	Animation           *TileAnimation
+	tileSet             *TileSet
 	*BasicImageParts
 	*BasicObject
 }
@@ -93,138 +158,324 @@ type Tile struct {
 type TileMap struct {
 	Layers                               []*TileLayer
 	Width, Height, TileWidth, TileHeight int
+	Orientation                          string
+	StaggerAxis, StaggerIndex            string
+	HexSideLength                        int
+	ParallaxOriginX, ParallaxOriginY     float64
+	Infinite                             bool
+	RenderOrder                          string
+	BackgroundColor                      color.Color
+	Properties                           Properties
+	TileSets                             []*TileSet
+	OnTileChange                         func(layer, col, row int)
+	animations                           map[int]*TileAnimation
+	layout                               *tileLayout
 }
 
-//TileLayer is the renderable layer used by the game
+//TileLayer is the renderable layer used by the game.
+//An Opacity of 0 is treated as fully opaque, use Hidden to hide the layer.
+//The tiles of a tile layer are also indexed by column and row starting at GridX, GridY so they can be culled by a Camera.
+//Static layers have no animated tiles and may be pre-rendered into chunk images.
+//ParallaxX and ParallaxY scroll the layer at a different speed to the map when drawn through a Camera,
+//1 moves with the map. Like Opacity a factor of 0 is treated as 1, use FixedX and FixedY to keep the layer
+//fixed on the screen as a Tiled parallax factor of 0 does. Image layers can repeat to fill the screen.
 type TileLayer struct {
-	Data          []*Tile
-	DrawOrder     int
-	Collide       bool
-	Above         bool
-	Name          string
-	IsImageLayer  bool
-	ImageName     string
-	X, Y          float64
-	Width, Height int
-	Properties    []*Property
-}
-
-//ReadMap from JSON file and dump into Map
-func ReadMap(filename string) *Map {
-	raw, err := ioutil.ReadFile(filename)
-	if err != nil {
-		log.Println(err.Error())
-		os.Exit(1)
-	}
+	ID               int
+	Data             []*Tile
+	DrawOrder        int
+	Collide          bool
+	Above            bool
+	Name             string
+	IsImageLayer     bool
+	IsObjectLayer    bool
+	ImageName        string
+	Image            string
+	X, Y             float64
+	OffsetX, OffsetY float64
+	Opacity          float64
+	Tint             color.Color
+	Hidden           bool
+	ParallaxX        float64
+	ParallaxY        float64
+	FixedX, FixedY   bool
+	RepeatX, RepeatY bool
+	Width, Height    int
+	GridX, GridY     int
+	TileWidth        int
+	TileHeight       int
+	Static           bool
+	ChunkSize        int
+	Properties       Properties
+	Objects          []*MapObject
+	grid             []*Tile
+	overhangX        int
+	overhangY        int
+	chunkSize        int
+	chunks           []*tileChunk
+	layout           *tileLayout
+	parallaxOriginX  float64
+	parallaxOriginY  float64
+}
 
-	m := &Map{}
-	json.Unmarshal(raw, m)
-	return m
+//ReadMap reads a Tiled map in either the .tmx or .json format from a file and dump into Map.
+//External tilesets are loaded relative to the map.
+func ReadMap(filename string) (*Map, error) {
+	return ReadMapFS(osFS{}, filepath.ToSlash(filename))
 }
 
-//ReadMapfromString reads an entire Map struct from a given string (represented by json)
-func ReadMapfromString(jString string) *Map {
-	raw := []byte(jString)
+//osFS opens files of the operating system by their slash separated path.
+//Unlike os.DirFS it allows the ../ of tilesets kept next to the directory of a map.
+type osFS struct{}
 
-	m := &Map{}
-	json.Unmarshal(raw, m)
-	return m
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(filepath.FromSlash(name))
 }
 
-//ReadMapfromByte reads an entire Map struct from a byte slice
-func ReadMapfromByte(byteMap []byte) *Map {
+//ReadMapfromString reads an entire Map struct from a given string (represented by json)
+func ReadMapfromString(jString string) (*Map, error) {
+	return ReadMapfromByte([]byte(jString))
+}
 
+//ReadMapfromByte reads an entire Map struct from a byte slice
+func ReadMapfromByte(byteMap []byte) (*Map, error) {
 	m := &Map{}
-	json.Unmarshal(byteMap, m)
-	return m
+	if err := unmarshalJSON("", byteMap, m); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
 
 //CreateTileMapFromFile creates a new TileMap based on the given filelocation
-func CreateTileMapFromFile(fileLocation string) *TileMap {
-	tilemap := ReadMap(fileLocation)
-	return CreateTileMap(tilemap)
-
+func CreateTileMapFromFile(fileLocation string) (*TileMap, error) {
+	tilemap, err := ReadMap(fileLocation)
+	if err != nil {
+		return nil, err
+	}
+	tm := CreateTileMap(tilemap)
+	watchTileMap(tm, fileLocation)
+	return tm, nil
 }
 
 //CreateTileMap creates a renderable TileMap
 func CreateTileMap(tilemap *Map) *TileMap {
 	tm := &TileMap{
-		Layers:     []*TileLayer{},
-		Width:      tilemap.Width,
-		Height:     tilemap.Height,
-		TileHeight: tilemap.TileHeight,
-		TileWidth:  tilemap.TileWidth,
+		Layers:          []*TileLayer{},
+		Width:           tilemap.Width,
+		Height:          tilemap.Height,
+		TileHeight:      tilemap.TileHeight,
+		TileWidth:       tilemap.TileWidth,
+		Orientation:     tilemap.Orientation,
+		StaggerAxis:     tilemap.StaggerAxis,
+		StaggerIndex:    tilemap.StaggerIndex,
+		HexSideLength:   tilemap.HexSideLength,
+		ParallaxOriginX: tilemap.ParallaxOriginX,
+		ParallaxOriginY: tilemap.ParallaxOriginY,
+		Infinite:        tilemap.Infinite,
+		Properties:      tilemap.Properties,
+		TileSets:        tilemap.TileSets,
+		RenderOrder:     tilemap.RenderOrder,
+	}
+	tm.layout = newTileLayout(tm)
+	if c, ok := ParseTiledColor(tilemap.BackgroundColor); ok {
+		tm.BackgroundColor = c
 	}
 	PrepareTileSet(tilemap)
-	for _, layer := range tilemap.Layers {
-		if layer.Type == "imagelayer" {
-			tl := &TileLayer{
-				Name: layer.Name,
-				//ImageName:    layer.ImageName,
+	tm.addLayers(tilemap.Layers, layerParent{opacity: 1, visible: true, parallaxX: 1, parallaxY: 1})
+
+	return tm
+}
+
+//layerParent carries the offset, opacity, visibility, tint and parallax of the group layers above a layer
+type layerParent struct {
+	offsetX, offsetY     float64
+	opacity              float64
+	visible              bool
+	tint                 color.Color
+	parallaxX, parallaxY float64
+}
+
+//addLayers converts the Tiled layers into TileLayers, flattening group layers
+func (tm *TileMap) addLayers(layers []*Layer, parent layerParent) {
+	for _, layer := range layers {
+		offsetX := parent.offsetX + layer.OffsetX
+		offsetY := parent.offsetY + layer.OffsetY
+		opacity := parent.opacity * layer.Opacity
+		visible := parent.visible && layer.Visible
+		tint := parent.tint
+		if c, ok := ParseTiledColor(layer.TintColor); ok {
+			tint = multiplyColor(parent.tint, c)
+		}
+		parallaxX, parallaxY := layer.Parallax()
+		parallaxX *= parent.parallaxX
+		parallaxY *= parent.parallaxY
+		var tl *TileLayer
+		switch layer.Type {
+		case "group":
+			tm.addLayers(layer.Layers, layerParent{
+				offsetX:   offsetX,
+				offsetY:   offsetY,
+				opacity:   opacity,
+				visible:   visible,
+				tint:      tint,
+				parallaxX: parallaxX,
+				parallaxY: parallaxY,
+			})
+		case "imagelayer":
+			//The image is looked up in the ImageManager by its path as written in the map
+			tl = &TileLayer{
+				ID:           layer.ID,
+				Name:         layer.Name,
+				ImageName:    layer.Image,
+				Image:        layer.Image,
 				IsImageLayer: true,
 				X:            float64(layer.X),
 				Y:            float64(layer.Y),
-				Width:        layer.Width * tilemap.TileWidth,
-				Height:       layer.Height * tilemap.TileHeight,
+				OffsetX:      offsetX,
+				OffsetY:      offsetY,
+				Opacity:      opacity,
+				Hidden:       !visible || opacity == 0,
+				RepeatX:      layer.RepeatX,
+				RepeatY:      layer.RepeatY,
+				Width:        layer.Width * tm.TileWidth,
+				Height:       layer.Height * tm.TileHeight,
 				Properties:   layer.Properties,
 			}
 			tm.Layers = append(tm.Layers, tl)
-		}
-		if layer.Type == "tilelayer" {
-			tl := &TileLayer{
-				Name: layer.Name,
-				Data: []*Tile{},
+		case "tilelayer":
+			tl = &TileLayer{
+				ID:         layer.ID,
+				Name:       layer.Name,
+				Data:       []*Tile{},
+				OffsetX:    offsetX,
+				OffsetY:    offsetY,
+				Opacity:    opacity,
+				Hidden:     !visible || opacity == 0,
+				Width:      layer.Width,
+				Height:     layer.Height,
+				TileWidth:  tm.TileWidth,
+				TileHeight: tm.TileHeight,
+				Properties: layer.Properties,
+				layout:     tm.layout,
 			}
-			/*if layer.Properties["collision"] != nil {
-				tl.Collide = true
-			}
-			if layer.Properties["above"] != nil {
-				tl.Above = true
-			}*/
-			rowRange := 0
-			//rowTiles := []*Tile{}
-
-			x := 0.0
-			y := 0.0
-			for _, tileData := range layer.Data {
-
-				rowRange++
-				if tileData != 0 {
-					t := &Tile{
-						Gid:         tileData,
-						BasicObject: NewBasicObject(x, y, tilemap.TileWidth, tilemap.TileHeight),
-						ImageName:   tl.ImageName,
-					}
-					t.NotCentered = true
-					t.DetermineTileSet(tilemap)
-					tl.Data = append(tl.Data, t)
-
+			tl.initGrid(layer)
+			tl.Collide = layer.Properties.GetBool("collision") || layer.Properties.GetBool("collide")
+			tl.Above = layer.Properties.GetBool("above")
+			if len(layer.Chunks) > 0 {
+				for _, chunk := range layer.Chunks {
+					tl.addTileData(tm, chunk.Data, chunk.X, chunk.Y, chunk.Width)
 				}
-				x += float64(tilemap.TileWidth)
-				//Determine whether to start a new row
-				if rowRange >= layer.Width {
-					rowRange = 0
-					x = 0.0 //Determine whether to start a new row
-					if rowRange > layer.Width {
-						rowRange = 0
-						x = 0.0
-
-					}
-					y += float64(tilemap.TileHeight)
-
+			} else {
+				tl.addTileData(tm, layer.Data, layer.X, layer.Y, layer.Width)
+			}
+			tl.Static = true
+			for _, t := range tl.Data {
+				if t.Animation != nil {
+					tl.Static = false
 				}
+				tl.applyLayerCollision(t)
 			}
 			tm.Layers = append(tm.Layers, tl)
 			//tm.Layers[i] = tl
+		case "objectgroup":
+			tl = &TileLayer{
+				ID:            layer.ID,
+				Name:          layer.Name,
+				Data:          []*Tile{},
+				IsObjectLayer: true,
+				OffsetX:       offsetX,
+				OffsetY:       offsetY,
+				Opacity:       opacity,
+				Hidden:        !visible || opacity == 0,
+				Properties:    layer.Properties,
+				Objects:       layer.Objects,
+			}
+			for _, obj := range layer.Objects {
+				if obj.Gid == 0 || !obj.Visible {
+					continue
+				}
+				//Tile objects are positioned by their bottom left corner, or bottom center on isometric maps
+				x, y := tm.layout.objectToWorld(obj.X, obj.Y)
+				if tm.Orientation == OrientationIsometric {
+					x -= obj.Width / 2
+				}
+				t := tm.newTile(obj.Gid, x+offsetX, y-obj.Height+offsetY)
+				if t.BasicImageParts != nil {
+					t.SetDestinationDimensions(int(obj.Width), int(obj.Height))
+				}
+				t.SetSize(int(obj.Width), int(obj.Height))
+				t.Angle = obj.Rotation * math.Pi / 180
+				tl.Data = append(tl.Data, t)
+			}
+			tm.Layers = append(tm.Layers, tl)
+		}
+		if tl != nil {
+			tl.Tint = tint
+			tl.ParallaxX, tl.ParallaxY = parallaxX, parallaxY
+			tl.FixedX, tl.FixedY = parallaxX == 0, parallaxY == 0
+			tl.parallaxOriginX, tl.parallaxOriginY = tm.ParallaxOriginX, tm.ParallaxOriginY
 		}
 	}
+}
 
-	return tm
+//applyLayerCollision makes every tile of a collision layer solid unless the tile says otherwise
+func (tl *TileLayer) applyLayerCollision(t *Tile) {
+	if tl.Collide && !(t.Properties.Has("collide") && !t.Properties.GetBool("collide")) {
+		t.Collide = true
+	}
+}
+
+//addTileData creates the Tiles of a block of layer data that starts at column startX and row startY
+func (tl *TileLayer) addTileData(tm *TileMap, data []int, startX, startY, width int) {
+	if width <= 0 {
+		return
+	}
+	for i, tileData := range data {
+		if tileData == 0 {
+			continue
+		}
+		col, row := startX+i%width, startY+i/width
+		t := tm.newGridTile(uint32(tileData), col, row, tl.OffsetX, tl.OffsetY)
+		tl.Data = append(tl.Data, t)
+		tl.setGridTile(col, row, t)
+	}
+}
+
+//newTile creates a Tile at x,y from a raw Tiled GID which may include flip flags
+func (tm *TileMap) newTile(raw uint32, x, y float64) *Tile {
+	gid, flipH, flipV, flipD := DecodeGID(raw)
+	t := &Tile{
+		Gid:         gid,
+		FlipH:       flipH,
+		FlipV:       flipV,
+		FlipD:       flipD,
+		BasicObject: NewBasicObject(x, y, tm.TileWidth, tm.TileHeight),
+	}
+	t.NotCentered = true
+	t.determineTileSet(tm.TileSets)
+	if t.TileData != nil && len(t.TileData.Animation) > 0 {
+		t.Animation = tm.tileAnimation(t.tileSet, gid, t.TileData.Animation)
+	}
+	return t
+}
+
+//newGridTile creates the Tile for the cell at the column and row of a tile layer.
+//Tiles on isometric, staggered and hexagonal maps are drawn with their bottom on the bottom of the cell like Tiled.
+func (tm *TileMap) newGridTile(raw uint32, col, row int, offsetX, offsetY float64) *Tile {
+	x, y := tm.TileToWorld(col, row)
+	t := tm.newTile(raw, x+offsetX, y+offsetY)
+	if !tm.tileLayout().orthogonal() && t.BasicImageParts != nil {
+		t.AddY(float64(tm.TileHeight - t.DestHeight))
+	}
+	return t
 }
 
 //DetermineTileSet of the given tile based on the GID
 func (t *Tile) DetermineTileSet(tilemap *Map) {
-	for _, tileSet := range tilemap.TileSets {
+	t.determineTileSet(tilemap.TileSets)
+}
+
+func (t *Tile) determineTileSet(tileSets []*TileSet) {
+	for _, tileSet := range tileSets {
 		if tileSet.FirstGID <= t.Gid {
 			if tileSet.LastGID >= t.Gid || tileSet.LastGID == tileSet.FirstGID {
 				//t.Image = tileSet.Image
@@ -239,53 +490,137 @@ func (t *Tile) DetermineTileSet(tilemap *Map) {
 					DestWidth:  tileSet.TileWidth,
 					DestHeight: tileSet.TileHeight,
 				}
+				t.tileSet = tileSet
+				t.Type = ""
+				t.Properties = nil
+				t.TileData = tileSet.TileData(t.Gid - tileSet.FirstGID)
+				if t.TileData != nil {
+					t.Type = t.TileData.Type
+					t.Properties = t.TileData.Properties
+				}
+				t.applyCollisionProperties()
 			}
 		}
 	}
 }
 
+//TileData returns the extra Tiled data of the tile with the given local id, nil if there is none
+func (ts *TileSet) TileData(id int) *TileSetTile {
+	for _, tile := range ts.Tiles {
+		if tile.ID == id {
+			return tile
+		}
+	}
+	return nil
+}
+
 //Draw a single renderable Tile
 func (t *Tile) Draw(screen *ebiten.Image, imageManager *ImageManager) error {
+	return t.draw(screen, imageManager, nil, nil)
+}
+
+//draw the Tile with its flips and rotation, followed by the world transform and color matrix of its layer if there are any
+func (t *Tile) draw(screen *ebiten.Image, imageManager *ImageManager, colorM *ebiten.ColorM, world *ebiten.GeoM) error {
+	if t.ImageName == "" || t.BasicImageParts == nil {
+		return nil
+	}
+
+	if t.Animation != nil {
+		if f := t.Animation.CurrentFrame(); f != nil {
+			t.Sx, t.Sy = f.Sx, f.Sy
+		}
+	}
 
+	img := imageManager.ReturnImage(t.ImageName)
 	op := &ebiten.DrawImageOptions{}
-	op.ImageParts = t.BasicImageParts
+	op.ImageParts = ImagePartsFor(img, t.BasicImageParts)
+	t.applyFlip(&op.GeoM)
+	if t.Angle != 0 {
+		//Rotated tiles come from tile objects which rotate around their bottom left corner
+		op.GeoM.Translate(0, -t.GetHeightF())
+		op.GeoM.Rotate(t.Angle)
+		op.GeoM.Translate(0, t.GetHeightF())
+	}
 	//op.GeoM.Translate(float64(-t.BasicObject.Width/2), float64(-t.BasicObject.Height/2))
 	//op.GeoM.Scale(float64(scalex), float64(scaley))
 	op.GeoM.Translate(t.GetX(), t.GetY())
+	if world != nil {
+		op.GeoM.Concat(*world)
+	}
+	if colorM != nil {
+		op.ColorM = *colorM
+	}
 
 	//log.Printf("%v,%v\n", scalex, scaley)
 	//ApplyCameraTransform(op, true)
-	//if Components.Camera.OnScreen(t.X, t.Y) {
-	if t.ImageName != "" {
-		screen.DrawImage(imageManager.ReturnImage(t.ImageName), op)
-	}
-	//}
+	screen.DrawImage(img, op)
 
 	return nil
 }
 
+//applyFlip adds the Tiled flips of the tile to the GeoM.
+//Tiled applies the diagonal flip first, then horizontal and vertical.
+func (t *Tile) applyFlip(geoM *ebiten.GeoM) {
+	if !t.FlipH && !t.FlipV && !t.FlipD {
+		return
+	}
+	w, h := float64(t.DestWidth), float64(t.DestHeight)
+	geoM.Translate(-w/2, -h/2)
+	if t.FlipD {
+		var swap ebiten.GeoM
+		swap.SetElement(0, 0, 0)
+		swap.SetElement(0, 1, 1)
+		swap.SetElement(1, 0, 1)
+		swap.SetElement(1, 1, 0)
+		geoM.Concat(swap)
+	}
+	if t.FlipH {
+		geoM.Scale(-1, 1)
+	}
+	if t.FlipV {
+		geoM.Scale(1, -1)
+	}
+	geoM.Translate(w/2, h/2)
+}
+
 //Draw the entire TileLayer
 func (tl *TileLayer) Draw(screen *ebiten.Image, imageManager *ImageManager) error {
+	if tl.Hidden {
+		return nil
+	}
 	if tl.IsImageLayer {
+		img := imageManager.ReturnImage(tl.ImageName)
+		if img == nil {
+			return nil
+		}
 		op := &ebiten.DrawImageOptions{}
 		op.ImageParts = &BasicImageParts{
 			Height: tl.Height,
 			Width:  tl.Width,
 		}
+		if tl.Width == 0 || tl.Height == 0 {
+			w, h := img.Size()
+			op.ImageParts = &BasicImageParts{Width: w, Height: h}
+		}
+		op.ImageParts = ImagePartsFor(img, op.ImageParts)
 		//op.GeoM.Translate(float64(-t.BasicObject.Width/2), float64(-t.BasicObject.Height/2))
 		//op.GeoM.Scale(float64(scalex), float64(scaley))
-		op.GeoM.Translate(tl.X, tl.Y)
+		op.GeoM.Translate(tl.X+tl.OffsetX, tl.Y+tl.OffsetY)
+		if colorM := tl.colorM(); colorM != nil {
+			op.ColorM = *colorM
+		}
 
 		//log.Printf("%v,%v\n", scalex, scaley)
 		//ApplyCameraTransform(op, true)
 
-		screen.DrawImage(imageManager.ReturnImage(tl.ImageName), op)
+		screen.DrawImage(img, op)
 
 		return nil
 	}
 
+	colorM := tl.colorM()
 	for x := range tl.Data {
-		tl.Data[x].Draw(screen, imageManager)
+		tl.Data[x].draw(screen, imageManager, colorM, nil)
 	}
 	return nil
 }
@@ -305,7 +640,10 @@ func PrepareTileSet(tilemap *Map) { //, imageManager *ImageManager) {
 
 		//tilemap.TileSets[x].Image = image
 		tilemap.TileSets[x].LastGID = tileSet.FirstGID + tileSet.TileCount
-		tilemap.TileSets[x].Rows = tileSet.ImageHeight / tileSet.TileHeight
+		//External tilesets that have not been loaded have no size yet
+		if tileSet.TileHeight > 0 {
+			tilemap.TileSets[x].Rows = tileSet.ImageHeight / tileSet.TileHeight
+		}
 	}
 }
 
@@ -0,0 +1,361 @@
+package tentsuyu
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+//tileChunk is a pre-rendered block of ChunkSize x ChunkSize tiles of a static TileLayer
+type tileChunk struct {
+	image *ebiten.Image
+	built bool
+}
+
+//initGrid sizes the column and row index of a tile layer, covering every chunk of an infinite layer
+func (tl *TileLayer) initGrid(layer *Layer) {
+	x0, y0 := layer.X, layer.Y
+	x1, y1 := layer.X+layer.Width, layer.Y+layer.Height
+	for i, c := range layer.Chunks {
+		if i == 0 {
+			x0, y0, x1, y1 = c.X, c.Y, c.X+c.Width, c.Y+c.Height
+			continue
+		}
+		x0 = minInt(x0, c.X)
+		y0 = minInt(y0, c.Y)
+		x1 = maxInt(x1, c.X+c.Width)
+		y1 = maxInt(y1, c.Y+c.Height)
+	}
+	tl.GridX, tl.GridY = x0, y0
+	tl.Width, tl.Height = x1-x0, y1-y0
+	tl.grid = nil
+	if tl.Width > 0 && tl.Height > 0 {
+		tl.grid = make([]*Tile, tl.Width*tl.Height)
+	}
+}
+
+//gridIndex returns the index of the column and row in the grid, -1 if it is outside the layer
+func (tl *TileLayer) gridIndex(col, row int) int {
+	col -= tl.GridX
+	row -= tl.GridY
+	if col < 0 || row < 0 || col >= tl.Width || row >= tl.Height || tl.grid == nil {
+		return -1
+	}
+	return row*tl.Width + col
+}
+
+//setGridTile places the Tile at the column and row of the grid
+func (tl *TileLayer) setGridTile(col, row int, t *Tile) {
+	i := tl.gridIndex(col, row)
+	if i < 0 {
+		return
+	}
+	tl.grid[i] = t
+	if t != nil && t.BasicImageParts != nil {
+		//Tiles bigger than the map grid hang over the cells to their right and below
+		tl.overhangX = maxInt(tl.overhangX, t.DestWidth-tl.TileWidth)
+		tl.overhangY = maxInt(tl.overhangY, t.DestHeight-tl.TileHeight)
+	}
+}
+
+//TileAt returns the Tile at the column and row of the layer, nil if the cell is empty or outside the layer
+func (tl *TileLayer) TileAt(col, row int) *Tile {
+	i := tl.gridIndex(col, row)
+	if i < 0 {
+		return nil
+	}
+	return tl.grid[i]
+}
+
+//VisibleRange returns the columns and rows of the layer inside the world area x0,y0 to x1,y1.
+//col1 and row1 are exclusive and the range is empty when the area is outside the layer.
+func (tl *TileLayer) VisibleRange(x0, y0, x1, y1 float64) (col0, row0, col1, row1 int) {
+	if tl.TileWidth <= 0 || tl.TileHeight <= 0 {
+		return 0, 0, 0, 0
+	}
+	tw, th := float64(tl.TileWidth), float64(tl.TileHeight)
+	if tl.layout.orthogonal() {
+		col0 = int(math.Floor((x0 - tl.OffsetX - float64(tl.overhangX)) / tw))
+		row0 = int(math.Floor((y0 - tl.OffsetY - float64(tl.overhangY)) / th))
+		col1 = int(math.Floor((x1-tl.OffsetX)/tw)) + 1
+		row1 = int(math.Floor((y1-tl.OffsetY)/th)) + 1
+	} else {
+		//Take the tiles under each corner of the area, with a margin for the tiles that stick out of their cell
+		x0, y0, x1, y1 = x0-tl.OffsetX, y0-tl.OffsetY, x1-tl.OffsetX, y1-tl.OffsetY
+		col0, row0 = tl.layout.worldToTile(x0, y0)
+		col1, row1 = col0, row0
+		for _, p := range [][2]float64{{x1, y0}, {x0, y1}, {x1, y1}} {
+			c, r := tl.layout.worldToTile(p[0], p[1])
+			col0, row0 = minInt(col0, c), minInt(row0, r)
+			col1, row1 = maxInt(col1, c), maxInt(row1, r)
+		}
+		margin := 2 + 2*int(math.Ceil(float64(maxInt(tl.overhangX, tl.overhangY))/math.Min(tw, th)))
+		col0, row0 = col0-margin, row0-margin
+		col1, row1 = col1+margin+1, row1+margin+1
+	}
+	col0 = maxInt(col0, tl.GridX)
+	row0 = maxInt(row0, tl.GridY)
+	col1 = minInt(col1, tl.GridX+tl.Width)
+	row1 = minInt(row1, tl.GridY+tl.Height)
+	if col1 < col0 {
+		col1 = col0
+	}
+	if row1 < row0 {
+		row1 = row0
+	}
+	return col0, row0, col1, row1
+}
+
+//DrawCamera draws only the part of the layer that is visible through the camera, applying the camera transform.
+//Static layers of orthogonal maps with a ChunkSize are drawn from cached chunk images.
+func (tl *TileLayer) DrawCamera(screen *ebiten.Image, imageManager *ImageManager, camera *Camera) error {
+	if tl.Hidden {
+		return nil
+	}
+	cop := &ebiten.DrawImageOptions{}
+	camera.DrawCameraTransform(cop)
+	world := cop.GeoM
+	colorM := tl.colorM()
+
+	if tl.IsImageLayer {
+		img := imageManager.ReturnImage(tl.ImageName)
+		if img == nil {
+			return nil
+		}
+		//Image layers from Tiled have no size of their own so they use the size of the image
+		w, h := tl.Width, tl.Height
+		if w == 0 || h == 0 {
+			w, h = img.Size()
+		}
+		px, py := tl.parallaxOffset(camera)
+		drawRepeated(screen, img, tl.X+tl.OffsetX+px, tl.Y+tl.OffsetY+py, w, h, tl.RepeatX, tl.RepeatY, colorM, world, camera)
+		return nil
+	}
+
+	//Move the layer by its parallax, the visible area moves the opposite way
+	px, py := tl.parallaxOffset(camera)
+	var shift ebiten.GeoM
+	shift.Translate(px, py)
+	shift.Concat(world)
+	world = shift
+	x0, y0, x1, y1 := camera.VisibleBounds()
+	x0, y0, x1, y1 = x0-px, y0-py, x1-px, y1-py
+
+	if tl.grid == nil {
+		//Object layers have no grid so check each tile, allowing for rotation around the corner
+		for _, t := range tl.Data {
+			margin := math.Max(t.GetWidthF(), t.GetHeightF())
+			if t.GetX()+margin < x0 || t.GetX()-margin > x1 || t.GetY()+margin < y0 || t.GetY()-margin > y1 {
+				continue
+			}
+			if err := t.draw(screen, imageManager, colorM, &world); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	col0, row0, col1, row1 := tl.VisibleRange(x0, y0, x1, y1)
+	orthogonal := tl.layout.orthogonal()
+	if tl.Static && tl.ChunkSize > 0 && orthogonal {
+		return tl.drawChunks(screen, imageManager, world, col0, row0, col1, row1)
+	}
+	//Draw one tileset image at a time so ebiten can batch the draw calls,
+	//unless tiles overlap each other and the order matters
+	var names []string
+	if orthogonal && tl.overhangX == 0 && tl.overhangY == 0 {
+		names = tl.imageNames(col0, row0, col1, row1)
+	}
+	if len(names) < 2 {
+		names = []string{""}
+	}
+	var err error
+	for _, name := range names {
+		for row := row0; row < row1; row++ {
+			tl.layout.drawOrder(col0, col1, func(col int) {
+				t := tl.grid[(row-tl.GridY)*tl.Width+col-tl.GridX]
+				if err != nil || t == nil || (name != "" && t.ImageName != name) {
+					return
+				}
+				err = t.draw(screen, imageManager, colorM, &world)
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//imageNames returns the tileset images used by the tiles in the range in the order they are first seen
+func (tl *TileLayer) imageNames(col0, row0, col1, row1 int) []string {
+	names := []string{}
+	for row := row0; row < row1; row++ {
+		for col := col0; col < col1; col++ {
+			t := tl.grid[(row-tl.GridY)*tl.Width+col-tl.GridX]
+			if t == nil {
+				continue
+			}
+			found := false
+			for _, n := range names {
+				if n == t.ImageName {
+					found = true
+					break
+				}
+			}
+			if !found {
+				names = append(names, t.ImageName)
+			}
+		}
+	}
+	return names
+}
+
+//drawChunks draws the cached chunks covering the visible tiles, rendering any that are missing
+func (tl *TileLayer) drawChunks(screen *ebiten.Image, imageManager *ImageManager, world ebiten.GeoM, col0, row0, col1, row1 int) error {
+	n := tl.ChunkSize
+	if n != tl.chunkSize {
+		tl.InvalidateCache()
+		tl.chunkSize = n
+	}
+	chunksW := (tl.Width + n - 1) / n
+	chunksH := (tl.Height + n - 1) / n
+	if len(tl.chunks) != chunksW*chunksH {
+		tl.chunks = make([]*tileChunk, chunksW*chunksH)
+	}
+	if col1 <= col0 || row1 <= row0 {
+		return nil
+	}
+	cx0, cy0 := (col0-tl.GridX)/n, (row0-tl.GridY)/n
+	cx1, cy1 := (col1-1-tl.GridX)/n, (row1-1-tl.GridY)/n
+	for cy := cy0; cy <= cy1; cy++ {
+		for cx := cx0; cx <= cx1; cx++ {
+			chunk := tl.chunks[cy*chunksW+cx]
+			if chunk == nil {
+				chunk = &tileChunk{}
+				tl.chunks[cy*chunksW+cx] = chunk
+			}
+			if !chunk.built {
+				if err := tl.renderChunk(chunk, imageManager, cx, cy); err != nil {
+					return err
+				}
+			}
+			if chunk.image == nil {
+				continue
+			}
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(tl.chunkOrigin(cx, cy))
+			op.GeoM.Concat(world)
+			if colorM := tl.colorM(); colorM != nil {
+				op.ColorM = *colorM
+			}
+			screen.DrawImage(chunk.image, op)
+		}
+	}
+	return nil
+}
+
+//chunkOrigin returns the world position of the top left corner of the chunk
+func (tl *TileLayer) chunkOrigin(cx, cy int) (float64, float64) {
+	return float64((tl.GridX+cx*tl.chunkSize)*tl.TileWidth) + tl.OffsetX,
+		float64((tl.GridY+cy*tl.chunkSize)*tl.TileHeight) + tl.OffsetY
+}
+
+//renderChunk draws the tiles of the chunk into its image, leaving the image nil if the chunk is empty
+func (tl *TileLayer) renderChunk(chunk *tileChunk, imageManager *ImageManager, cx, cy int) error {
+	n := tl.chunkSize
+	col0, row0 := tl.GridX+cx*n, tl.GridY+cy*n
+	col1, row1 := minInt(col0+n, tl.GridX+tl.Width), minInt(row0+n, tl.GridY+tl.Height)
+	ox, oy := tl.chunkOrigin(cx, cy)
+	var local ebiten.GeoM
+	local.Translate(-ox, -oy)
+	for row := row0; row < row1; row++ {
+		for col := col0; col < col1; col++ {
+			t := tl.TileAt(col, row)
+			if t == nil {
+				continue
+			}
+			if chunk.image == nil {
+				img, err := ebiten.NewImage(n*tl.TileWidth+tl.overhangX, n*tl.TileHeight+tl.overhangY, ebiten.FilterDefault)
+				if err != nil {
+					return err
+				}
+				chunk.image = img
+			}
+			if err := t.draw(chunk.image, imageManager, nil, &local); err != nil {
+				return err
+			}
+		}
+	}
+	chunk.built = true
+	return nil
+}
+
+//InvalidateTile marks the cached chunk holding the column and row to be rendered again
+func (tl *TileLayer) InvalidateTile(col, row int) {
+	if tl.chunkSize <= 0 || tl.gridIndex(col, row) < 0 {
+		return
+	}
+	chunksW := (tl.Width + tl.chunkSize - 1) / tl.chunkSize
+	i := (row-tl.GridY)/tl.chunkSize*chunksW + (col-tl.GridX)/tl.chunkSize
+	if i < len(tl.chunks) && tl.chunks[i] != nil {
+		tl.chunks[i].dispose()
+	}
+}
+
+//InvalidateCache disposes every cached chunk image of the layer
+func (tl *TileLayer) InvalidateCache() {
+	for _, c := range tl.chunks {
+		if c != nil {
+			c.dispose()
+		}
+	}
+}
+
+func (c *tileChunk) dispose() {
+	if c.image != nil {
+		c.image.Dispose()
+		c.image = nil
+	}
+	c.built = false
+}
+
+//DrawCamera draws the parts of the TileMap that are visible through the camera
+func (tm *TileMap) DrawCamera(screen *ebiten.Image, imageManager *ImageManager, camera *Camera) error {
+	for _, l := range tm.Layers {
+		if err := l.DrawCamera(screen, imageManager, camera); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//SetChunkCache sets the ChunkSize, in tiles, of every static layer. 0 turns the cache off.
+func (tm *TileMap) SetChunkCache(chunkSize int) {
+	for _, l := range tm.Layers {
+		if l.Static {
+			l.ChunkSize = chunkSize
+		}
+	}
+}
+
+//InvalidateCache disposes the cached chunk images of every layer
+func (tm *TileMap) InvalidateCache() {
+	for _, l := range tm.Layers {
+		l.InvalidateCache()
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,264 @@
+package tentsuyu
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+//TransitionType is the style of animation used when switching between GameStates
+type TransitionType int
+
+//Available transitions
+const (
+	//TransitionFade fades the outgoing state to Color and then fades the incoming state in
+	TransitionFade TransitionType = iota
+	//TransitionCrossfade blends the outgoing state directly into the incoming state
+	TransitionCrossfade
+	//TransitionSlide pushes the outgoing state off screen in Direction as the incoming state slides in
+	TransitionSlide
+	//TransitionIris reveals the incoming state through a growing circle from the center of the screen
+	TransitionIris
+	//TransitionDissolve reveals the incoming state in randomly ordered blocks of pixels
+	TransitionDissolve
+)
+
+//SlideDirection is the direction a TransitionSlide moves the screen
+type SlideDirection int
+
+//Available slide directions
+const (
+	SlideLeft SlideDirection = iota
+	SlideRight
+	SlideUp
+	SlideDown
+)
+
+//dissolveBlockSize is the size in pixels of a single block of the TransitionDissolve
+const dissolveBlockSize = 4
+
+//irisRadius is the radius of the circle mask image used by TransitionIris
+const irisRadius = 256
+
+var irisImage *ebiten.Image
+
+//Transition animates the switch from one GameState to another.
+//The outgoing frame is captured before the old state exits, the incoming states are drawn offscreen every frame
+//and nothing is updated until the transition completes.
+type Transition struct {
+	Type       TransitionType
+	Duration   time.Duration
+	Color      color.Color
+	Direction  SlideDirection
+	OnComplete func()
+
+	elapsed                    time.Duration
+	fromImage, toImage, masked *ebiten.Image
+	dissolveOrder              []float64
+	dissolveMask               *ebiten.Image
+	dissolvePixels             []byte
+	dissolveW, dissolveH       int
+}
+
+//NewTransition returns a Transition of the given type that lasts for duration.
+//Color defaults to black and Direction to SlideLeft.
+func NewTransition(tType TransitionType, duration time.Duration) *Transition {
+	return &Transition{
+		Type:      tType,
+		Duration:  duration,
+		Color:     color.Black,
+		Direction: SlideLeft,
+	}
+}
+
+//Progress returns how far along the transition is from 0 to 1
+func (t *Transition) Progress() float64 {
+	if t.Duration <= 0 {
+		return 1
+	}
+	p := float64(t.elapsed) / float64(t.Duration)
+	if p > 1 {
+		return 1
+	}
+	return p
+}
+
+//Done returns true once the transition has run its full duration
+func (t *Transition) Done() bool {
+	return t.elapsed >= t.Duration
+}
+
+//start prepares the offscreen images for the transition
+func (t *Transition) start(w, h int) error {
+	t.elapsed = 0
+	var err error
+	if t.fromImage, err = ebiten.NewImage(w, h, ebiten.FilterDefault); err != nil {
+		return err
+	}
+	if t.toImage, err = ebiten.NewImage(w, h, ebiten.FilterDefault); err != nil {
+		return err
+	}
+	switch t.Type {
+	case TransitionIris:
+		if t.masked, err = ebiten.NewImage(w, h, ebiten.FilterDefault); err != nil {
+			return err
+		}
+		if irisImage == nil {
+			if irisImage, err = newCircleImage(irisRadius); err != nil {
+				return err
+			}
+		}
+	case TransitionDissolve:
+		if t.masked, err = ebiten.NewImage(w, h, ebiten.FilterDefault); err != nil {
+			return err
+		}
+		t.dissolveW = (w + dissolveBlockSize - 1) / dissolveBlockSize
+		t.dissolveH = (h + dissolveBlockSize - 1) / dissolveBlockSize
+		if t.dissolveMask, err = ebiten.NewImage(t.dissolveW, t.dissolveH, ebiten.FilterNearest); err != nil {
+			return err
+		}
+		t.dissolvePixels = make([]byte, 4*t.dissolveW*t.dissolveH)
+		t.dissolveOrder = make([]float64, t.dissolveW*t.dissolveH)
+		for i := range t.dissolveOrder {
+			t.dissolveOrder[i] = rand.Float64()
+		}
+	}
+	return nil
+}
+
+//update advances the transition by the passed amount of time
+func (t *Transition) update(dt time.Duration) {
+	t.elapsed += dt
+}
+
+//dispose releases the offscreen images once the transition is complete
+func (t *Transition) dispose() {
+	for _, img := range []*ebiten.Image{t.fromImage, t.toImage, t.masked, t.dissolveMask} {
+		if img != nil {
+			img.Dispose()
+		}
+	}
+	t.fromImage, t.toImage, t.masked, t.dissolveMask = nil, nil, nil, nil
+}
+
+//capture draws the states of the stack as they are before the transition, including those drawn below the top,
+//so the outgoing frame can be shown after the old state has exited
+func (t *Transition) capture(g *Game) error {
+	screen := g.Screen
+	err := t.renderStack(g, t.fromImage)
+	g.Screen = screen
+	return err
+}
+
+//Draw renders the incoming GameStates offscreen and blends them with the captured outgoing frame onto the game screen
+func (t *Transition) Draw(g *Game, screen *ebiten.Image) error {
+	err := t.renderStack(g, t.toImage)
+	g.Screen = screen
+	if err != nil {
+		return err
+	}
+
+	p := t.Progress()
+	w, h := t.fromImage.Size()
+	switch t.Type {
+	case TransitionFade:
+		r, gr, b, a := colorScale(t.Color)
+		src := t.fromImage
+		alpha := p * 2
+		if p >= 0.5 {
+			src = t.toImage
+			alpha = (1 - p) * 2
+		}
+		screen.DrawImage(src, &ebiten.DrawImageOptions{})
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(float64(w), float64(h))
+		op.ColorM.Scale(r, gr, b, a*alpha)
+		screen.DrawImage(Pixel, op)
+	case TransitionCrossfade:
+		screen.DrawImage(t.fromImage, &ebiten.DrawImageOptions{})
+		op := &ebiten.DrawImageOptions{}
+		op.ColorM.Scale(1, 1, 1, p)
+		screen.DrawImage(t.toImage, op)
+	case TransitionSlide:
+		dx, dy := 0.0, 0.0
+		switch t.Direction {
+		case SlideLeft:
+			dx = -float64(w)
+		case SlideRight:
+			dx = float64(w)
+		case SlideUp:
+			dy = -float64(h)
+		case SlideDown:
+			dy = float64(h)
+		}
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(dx*p, dy*p)
+		screen.DrawImage(t.fromImage, op)
+		op = &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(dx*(p-1), dy*(p-1))
+		screen.DrawImage(t.toImage, op)
+	case TransitionIris:
+		screen.DrawImage(t.fromImage, &ebiten.DrawImageOptions{})
+		t.masked.Clear()
+		t.masked.DrawImage(t.toImage, &ebiten.DrawImageOptions{})
+		radius := p * math.Hypot(float64(w), float64(h)) / 2
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(-irisRadius, -irisRadius)
+		op.GeoM.Scale(radius/irisRadius, radius/irisRadius)
+		op.GeoM.Translate(float64(w)/2, float64(h)/2)
+		op.CompositeMode = ebiten.CompositeModeDestinationIn
+		t.masked.DrawImage(irisImage, op)
+		screen.DrawImage(t.masked, &ebiten.DrawImageOptions{})
+	case TransitionDissolve:
+		for i, v := range t.dissolveOrder {
+			var a byte
+			if v < p {
+				a = 0xff
+			}
+			t.dissolvePixels[4*i] = a
+			t.dissolvePixels[4*i+1] = a
+			t.dissolvePixels[4*i+2] = a
+			t.dissolvePixels[4*i+3] = a
+		}
+		if err := t.dissolveMask.ReplacePixels(t.dissolvePixels); err != nil {
+			return err
+		}
+		screen.DrawImage(t.fromImage, &ebiten.DrawImageOptions{})
+		t.masked.Clear()
+		t.masked.DrawImage(t.toImage, &ebiten.DrawImageOptions{})
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(dissolveBlockSize, dissolveBlockSize)
+		op.CompositeMode = ebiten.CompositeModeDestinationIn
+		t.masked.DrawImage(t.dissolveMask, op)
+		screen.DrawImage(t.masked, &ebiten.DrawImageOptions{})
+	}
+	return nil
+}
+
+//renderStack draws the visible states of the stack to the passed image by pointing Game.Screen at it
+func (t *Transition) renderStack(g *Game, img *ebiten.Image) error {
+	img.Clear()
+	g.Screen = img
+	return g.States.Draw(g)
+}
+
+//newCircleImage returns a white filled circle of the given radius on a transparent background
+func newCircleImage(radius int) (*ebiten.Image, error) {
+	size := radius * 2
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	r2 := float64(radius * radius)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dx := float64(x-radius) + 0.5
+			dy := float64(y-radius) + 0.5
+			if dx*dx+dy*dy <= r2 {
+				img.Set(x, y, color.White)
+			}
+		}
+	}
+	return ebiten.NewImageFromImage(img, ebiten.FilterLinear)
+}
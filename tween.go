@@ -0,0 +1,481 @@
+package tentsuyu
+
+import (
+	"image/color"
+	"math"
+	"time"
+)
+
+//Tweener is a Tween, Sequence or Parallel group that a TweenManager or another group can play
+type Tweener interface {
+	//Update advances the tweener by dt seconds, returning true once it is done
+	Update(dt float64) bool
+	//Done returns true once the tweener has played to its end
+	Done() bool
+	//Reset rewinds the tweener so it plays again from the start
+	Reset()
+	//advance moves the tweener on by dt seconds and returns the seconds left over once it is done
+	advance(dt float64) float64
+}
+
+//Tween animates a value over Duration, after waiting Delay, following the Ease curve.
+//It plays Repeat more times after the first, forever if -1, and with Yoyo every other play runs backwards.
+//The start values are read from the target the first time the Tween starts.
+type Tween struct {
+	Duration   time.Duration
+	Delay      time.Duration
+	Ease       EaseFunc
+	Repeat     int
+	Yoyo       bool
+	OnStart    func()
+	OnComplete func()
+	apply      func(p float64)
+	begin      func()
+	begun      bool
+	started    bool
+	done       bool
+	delayed    float64
+	elapsed    float64
+	cycle      int
+}
+
+//NewTween returns a Tween calling apply with the eased progress, usually from 0 to 1, every time it advances.
+//A nil ease is Linear.
+func NewTween(duration time.Duration, ease EaseFunc, apply func(p float64)) *Tween {
+	return &Tween{
+		Duration: duration,
+		Ease:     ease,
+		apply:    apply,
+	}
+}
+
+//newTweenFrom returns a Tween calling begin once when it first starts, to read the start values of its target
+func newTweenFrom(duration time.Duration, ease EaseFunc, begin func(), apply func(p float64)) *Tween {
+	t := NewTween(duration, ease, apply)
+	t.begin = begin
+	return t
+}
+
+//TweenFloat returns a Tween moving the value to to
+func TweenFloat(v *float64, to float64, duration time.Duration, ease EaseFunc) *Tween {
+	var from float64
+	return newTweenFrom(duration, ease, func() {
+		from = *v
+	}, func(p float64) {
+		*v = lerp(from, to, p)
+	})
+}
+
+//TweenVector returns a Tween moving the vector to to
+func TweenVector(v *Vector2d, to Vector2d, duration time.Duration, ease EaseFunc) *Tween {
+	var from Vector2d
+	return newTweenFrom(duration, ease, func() {
+		from = *v
+	}, func(p float64) {
+		v.X = lerp(from.X, to.X, p)
+		v.Y = lerp(from.Y, to.Y, p)
+	})
+}
+
+//TweenColor returns a Tween fading the color, including its alpha, to to
+func TweenColor(c *color.RGBA, to color.RGBA, duration time.Duration, ease EaseFunc) *Tween {
+	var from color.RGBA
+	return newTweenFrom(duration, ease, func() {
+		from = *c
+	}, func(p float64) {
+		c.R = lerpUint8(from.R, to.R, p)
+		c.G = lerpUint8(from.G, to.G, p)
+		c.B = lerpUint8(from.B, to.B, p)
+		c.A = lerpUint8(from.A, to.A, p)
+	})
+}
+
+//TweenPosition returns a Tween moving the BasicObject to x, y
+func TweenPosition(obj *BasicObject, x, y float64, duration time.Duration, ease EaseFunc) *Tween {
+	var fromX, fromY float64
+	return newTweenFrom(duration, ease, func() {
+		fromX, fromY = obj.GetPosition()
+	}, func(p float64) {
+		obj.SetPosition(lerp(fromX, x, p), lerp(fromY, y, p))
+	})
+}
+
+//TweenAngle returns a Tween turning the BasicObject to the angle in radians
+func TweenAngle(obj *BasicObject, angle float64, duration time.Duration, ease EaseFunc) *Tween {
+	var from float64
+	return newTweenFrom(duration, ease, func() {
+		from = obj.GetAngle()
+	}, func(p float64) {
+		obj.SetAngle(lerp(from, angle, p))
+	})
+}
+
+//TweenUIElement returns a Tween moving the UIElement to x, y.
+//Elements without a GetPosition method, which UIElement does not require, are moved by x, y from where they are instead.
+func TweenUIElement(e UIElement, x, y float64, duration time.Duration, ease EaseFunc) *Tween {
+	if pos, ok := e.(interface {
+		GetPosition() (float64, float64)
+	}); ok {
+		var fromX, fromY float64
+		return newTweenFrom(duration, ease, func() {
+			fromX, fromY = pos.GetPosition()
+		}, func(p float64) {
+			e.SetPosition(lerp(fromX, x, p), lerp(fromY, y, p))
+		})
+	}
+	var movedX, movedY float64
+	return NewTween(duration, ease, func(p float64) {
+		e.AddPosition(x*p-movedX, y*p-movedY)
+		movedX, movedY = x*p, y*p
+	})
+}
+
+//TweenCamera returns a Tween moving the Camera to x, y
+func TweenCamera(c *Camera, x, y float64, duration time.Duration, ease EaseFunc) *Tween {
+	var fromX, fromY float64
+	return newTweenFrom(duration, ease, func() {
+		fromX, fromY = c.GetX(), c.GetY()
+	}, func(p float64) {
+		c.SetPosition(lerp(fromX, x, p), lerp(fromY, y, p))
+	})
+}
+
+//TweenCameraZoom returns a Tween zooming the Camera to the zoom level through SetZoom
+func TweenCameraZoom(c *Camera, zoom float64, duration time.Duration, ease EaseFunc) *Tween {
+	var from float64
+	return newTweenFrom(duration, ease, func() {
+		from = c.Zoom
+	}, func(p float64) {
+		c.SetZoom(lerp(from, zoom, p))
+	})
+}
+
+func lerp(from, to, p float64) float64 {
+	return from + (to-from)*p
+}
+
+//lerpUint8 interpolates a color channel, clamping curves that overshoot
+func lerpUint8(from, to uint8, p float64) uint8 {
+	return uint8(math.Max(0, math.Min(255, math.Round(lerp(float64(from), float64(to), p)))))
+}
+
+//Update advances the Tween by dt seconds, returning true once it is done
+func (t *Tween) Update(dt float64) bool {
+	t.advance(dt)
+	return t.done
+}
+
+func (t *Tween) advance(dt float64) float64 {
+	if t.done {
+		return dt
+	}
+	if !t.started {
+		t.delayed += dt
+		if t.delayed < t.Delay.Seconds() {
+			return 0
+		}
+		dt = t.delayed - t.Delay.Seconds()
+		t.start()
+	}
+	d := t.Duration.Seconds()
+	t.elapsed += dt
+	for {
+		if d > 0 && t.elapsed < d {
+			t.set(t.elapsed / d)
+			return 0
+		}
+		t.set(1)
+		if d > 0 {
+			t.elapsed -= d
+		}
+		if t.Repeat >= 0 && t.cycle >= t.Repeat {
+			t.done = true
+			if t.OnComplete != nil {
+				t.OnComplete()
+			}
+			return t.elapsed
+		}
+		t.cycle++
+		if d <= 0 {
+			//A Tween without a duration repeating forever plays once per update
+			return 0
+		}
+	}
+}
+
+//start reads the start values of the target the first time the Tween starts and calls OnStart
+func (t *Tween) start() {
+	t.started = true
+	if !t.begun {
+		t.begun = true
+		if t.begin != nil {
+			t.begin()
+		}
+	}
+	if t.OnStart != nil {
+		t.OnStart()
+	}
+}
+
+//set applies the progress through the current play, running backwards on the odd plays of a Yoyo
+func (t *Tween) set(p float64) {
+	if t.Yoyo && t.cycle%2 == 1 {
+		p = 1 - p
+	}
+	if t.apply == nil {
+		return
+	}
+	if t.Ease != nil {
+		p = t.Ease(p)
+	}
+	t.apply(p)
+}
+
+//Done returns true once the Tween has played Repeat+1 times
+func (t *Tween) Done() bool {
+	return t.done
+}
+
+//Reset rewinds the Tween to wait out its Delay and play again. The start values read when it first started are kept.
+func (t *Tween) Reset() {
+	t.started = false
+	t.done = false
+	t.delayed = 0
+	t.elapsed = 0
+	t.cycle = 0
+}
+
+//Sequence plays its tweeners one after another, Repeat more times after the first, forever if -1
+type Sequence struct {
+	Repeat     int
+	OnComplete func()
+	tweens     []Tweener
+	index      int
+	cycle      int
+	done       bool
+}
+
+//NewSequence returns a Sequence of the tweeners
+func NewSequence(tweens ...Tweener) *Sequence {
+	return &Sequence{tweens: tweens}
+}
+
+//Append adds the tweener to the end of the Sequence, returning the Sequence so calls can be chained
+func (s *Sequence) Append(t Tweener) *Sequence {
+	s.tweens = append(s.tweens, t)
+	return s
+}
+
+//AppendDelay adds a pause to the end of the Sequence
+func (s *Sequence) AppendDelay(d time.Duration) *Sequence {
+	return s.Append(NewTween(d, nil, nil))
+}
+
+//AppendCallback adds a call to fn to the end of the Sequence
+func (s *Sequence) AppendCallback(fn func()) *Sequence {
+	t := NewTween(0, nil, nil)
+	t.OnComplete = fn
+	return s.Append(t)
+}
+
+//Update advances the Sequence by dt seconds, returning true once it is done
+func (s *Sequence) Update(dt float64) bool {
+	s.advance(dt)
+	return s.done
+}
+
+func (s *Sequence) advance(dt float64) float64 {
+	if s.done {
+		return dt
+	}
+	for {
+		start := dt
+		for s.index < len(s.tweens) {
+			t := s.tweens[s.index]
+			dt = t.advance(dt)
+			if !t.Done() {
+				return 0
+			}
+			s.index++
+		}
+		if s.Repeat >= 0 && s.cycle >= s.Repeat {
+			s.done = true
+			if s.OnComplete != nil {
+				s.OnComplete()
+			}
+			return dt
+		}
+		s.cycle++
+		s.rewind()
+		if dt <= 0 || dt == start {
+			return 0
+		}
+	}
+}
+
+//rewind resets the tweeners of the Sequence to play them again
+func (s *Sequence) rewind() {
+	s.index = 0
+	for _, t := range s.tweens {
+		t.Reset()
+	}
+}
+
+//Done returns true once the Sequence has played Repeat+1 times
+func (s *Sequence) Done() bool {
+	return s.done
+}
+
+//Reset rewinds the Sequence and its tweeners
+func (s *Sequence) Reset() {
+	s.done = false
+	s.cycle = 0
+	s.rewind()
+}
+
+//Parallel plays its tweeners at the same time until all of them are done, Repeat more times after the first, forever if -1
+type Parallel struct {
+	Repeat     int
+	OnComplete func()
+	tweens     []Tweener
+	cycle      int
+	done       bool
+}
+
+//NewParallel returns a Parallel group of the tweeners
+func NewParallel(tweens ...Tweener) *Parallel {
+	return &Parallel{tweens: tweens}
+}
+
+//Add adds the tweener to the group, returning the group so calls can be chained
+func (pl *Parallel) Add(t Tweener) *Parallel {
+	pl.tweens = append(pl.tweens, t)
+	return pl
+}
+
+//Update advances the group by dt seconds, returning true once it is done
+func (pl *Parallel) Update(dt float64) bool {
+	pl.advance(dt)
+	return pl.done
+}
+
+func (pl *Parallel) advance(dt float64) float64 {
+	if pl.done {
+		return dt
+	}
+	for {
+		left := dt
+		for _, t := range pl.tweens {
+			if l := t.advance(dt); !t.Done() {
+				left = 0
+			} else if l < left {
+				left = l
+			}
+		}
+		if left <= 0 && !pl.allDone() {
+			return 0
+		}
+		if pl.Repeat >= 0 && pl.cycle >= pl.Repeat {
+			pl.done = true
+			if pl.OnComplete != nil {
+				pl.OnComplete()
+			}
+			return left
+		}
+		pl.cycle++
+		for _, t := range pl.tweens {
+			t.Reset()
+		}
+		if left <= 0 || left == dt {
+			return 0
+		}
+		dt = left
+	}
+}
+
+func (pl *Parallel) allDone() bool {
+	for _, t := range pl.tweens {
+		if !t.Done() {
+			return false
+		}
+	}
+	return true
+}
+
+//Done returns true once the group has played Repeat+1 times
+func (pl *Parallel) Done() bool {
+	return pl.done
+}
+
+//Reset rewinds the group and its tweeners
+func (pl *Parallel) Reset() {
+	pl.done = false
+	pl.cycle = 0
+	for _, t := range pl.tweens {
+		t.Reset()
+	}
+}
+
+//TweenManager plays tweeners until they are done. The Game updates its Tweens every fixed step
+//with Clock.SubsystemDelta(ClockTweens).
+type TweenManager struct {
+	tweens []Tweener
+}
+
+//NewTweenManager returns an empty TweenManager
+func NewTweenManager() *TweenManager {
+	return &TweenManager{}
+}
+
+//Add starts playing the tweeners
+func (m *TweenManager) Add(tweens ...Tweener) {
+	m.tweens = append(m.tweens, tweens...)
+}
+
+//Remove stops playing the tweener without completing it
+func (m *TweenManager) Remove(t Tweener) {
+	for i, tw := range m.tweens {
+		if tw == t {
+			m.tweens = append(m.tweens[:i], m.tweens[i+1:]...)
+			return
+		}
+	}
+}
+
+//Clear stops playing every tweener
+func (m *TweenManager) Clear() {
+	m.tweens = nil
+}
+
+//Len returns the number of tweeners playing
+func (m *TweenManager) Len() int {
+	return len(m.tweens)
+}
+
+//Playing returns true if the tweener is being played by the TweenManager
+func (m *TweenManager) Playing(t Tweener) bool {
+	for _, tw := range m.tweens {
+		if tw == t {
+			return true
+		}
+	}
+	return false
+}
+
+//Update advances every tweener by dt seconds and drops those that are done.
+//Tweeners added by callbacks start on the next Update.
+func (m *TweenManager) Update(dt float64) {
+	tweens := append([]Tweener(nil), m.tweens...)
+	for _, t := range tweens {
+		if m.Playing(t) {
+			t.advance(dt)
+		}
+	}
+	playing := m.tweens[:0]
+	for _, t := range m.tweens {
+		if !t.Done() {
+			playing = append(playing, t)
+		}
+	}
+	m.tweens = playing
+}
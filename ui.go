@@ -65,6 +65,11 @@ func (ui *UIController) AddFont(fntName string, tt *truetype.Font) {
 	ui.fonts[fntName] = tt
 }
 
+//RemoveFont removes the font with the given name from the ui fonts list
+func (ui *UIController) RemoveFont(fntName string) {
+	delete(ui.fonts, fntName)
+}
+
 //AddFontFromBytes adds the font through a byte slice
 func (ui *UIController) AddFontFromBytes(fntName string, bytes []byte) error {
 	fnt, err := truetype.Parse(bytes)
@@ -0,0 +1,440 @@
+package tentsuyu
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/rs/xid"
+)
+
+//World is an optional entity-component-system store. Entities are the xid IDs BasicObjects are given,
+//components are any values, usually pointers to structs, stored by their type, and systems run over
+//the entities returned by queries in the order they were added with.
+type World struct {
+	entities    []xid.ID
+	entityIndex map[xid.ID]int
+	stores      map[reflect.Type]*componentStore
+	objects     map[xid.ID]Identifier
+	systems     []worldSystem
+	nextSystem  int
+}
+
+//Identifier is anything with the ID of a BasicObject, including every type embedding one
+type Identifier interface {
+	GetID() xid.ID
+}
+
+//System updates the World every fixed step of the Game
+type System interface {
+	Update(w *World, g *Game) error
+}
+
+//DrawSystem is a System that also draws the World
+type DrawSystem interface {
+	System
+	Draw(w *World, g *Game) error
+}
+
+//SystemFunc is a System updating the World with the function
+type SystemFunc func(w *World, g *Game) error
+
+//Update calls the function
+func (f SystemFunc) Update(w *World, g *Game) error {
+	return f(w, g)
+}
+
+//DrawSystemFunc is a DrawSystem drawing the World with the function and not updating it
+type DrawSystemFunc func(w *World, g *Game) error
+
+//Update does nothing
+func (f DrawSystemFunc) Update(w *World, g *Game) error {
+	return nil
+}
+
+//Draw calls the function
+func (f DrawSystemFunc) Draw(w *World, g *Game) error {
+	return f(w, g)
+}
+
+//worldSystem is a System of a World with the name and order it was added with
+type worldSystem struct {
+	name   string
+	order  int
+	added  int
+	system System
+}
+
+//componentStore holds the components of one type, packed so they can be walked without gaps
+type componentStore struct {
+	entities   []xid.ID
+	components []interface{}
+	index      map[xid.ID]int
+}
+
+//NewWorld returns an empty World
+func NewWorld() *World {
+	return &World{
+		entityIndex: map[xid.ID]int{},
+		stores:      map[reflect.Type]*componentStore{},
+		objects:     map[xid.ID]Identifier{},
+	}
+}
+
+//NewEntity creates an entity with the components and returns its ID
+func (w *World) NewEntity(components ...interface{}) xid.ID {
+	id := xid.New()
+	w.AddEntity(id, components...)
+	return id
+}
+
+//AddEntity adds an entity with an existing ID, such as that of a BasicObject, and the components.
+//Components are added to the entity if it already exists.
+func (w *World) AddEntity(id xid.ID, components ...interface{}) {
+	if _, ok := w.entityIndex[id]; !ok {
+		w.entityIndex[id] = len(w.entities)
+		w.entities = append(w.entities, id)
+	}
+	w.Add(id, components...)
+}
+
+//AddObject adds the object as an entity with its ID. The object is a component, as is every struct it embeds
+//such as its *BasicObject, so systems can query (*BasicObject)(nil) to reach every wrapped object.
+func (w *World) AddObject(obj Identifier, components ...interface{}) xid.ID {
+	id := obj.GetID()
+	w.AddEntity(id, obj)
+	for _, c := range embeddedComponents(obj) {
+		w.Add(id, c)
+	}
+	w.Add(id, components...)
+	w.objects[id] = obj
+	return id
+}
+
+//embeddedComponents returns the structs embedded in the object as pointers, nil ones are skipped
+func embeddedComponents(obj interface{}) []interface{} {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	v = v.Elem()
+	var components []interface{}
+	for i := 0; i < v.NumField(); i++ {
+		f, field := v.Field(i), v.Type().Field(i)
+		if !field.Anonymous || field.PkgPath != "" {
+			continue
+		}
+		switch {
+		case f.Kind() == reflect.Ptr && !f.IsNil() && f.Elem().Kind() == reflect.Struct:
+			components = append(components, f.Interface())
+		case f.Kind() == reflect.Struct:
+			components = append(components, f.Addr().Interface())
+		}
+	}
+	return components
+}
+
+//Object returns the object added with AddObject for the entity, nil if it was not added as one
+func (w *World) Object(id xid.ID) Identifier {
+	return w.objects[id]
+}
+
+//RemoveEntity removes the entity and all of its components
+func (w *World) RemoveEntity(id xid.ID) {
+	i, ok := w.entityIndex[id]
+	if !ok {
+		return
+	}
+	copy(w.entities[i:], w.entities[i+1:])
+	w.entities = w.entities[:len(w.entities)-1]
+	delete(w.entityIndex, id)
+	for _, e := range w.entities[i:] {
+		w.entityIndex[e]--
+	}
+	for _, s := range w.stores {
+		s.remove(id)
+	}
+	delete(w.objects, id)
+}
+
+//Alive returns true if the entity is in the World
+func (w *World) Alive(id xid.ID) bool {
+	_, ok := w.entityIndex[id]
+	return ok
+}
+
+//Entities returns every entity in the order they were added
+func (w *World) Entities() []xid.ID {
+	return append([]xid.ID(nil), w.entities...)
+}
+
+//Len returns the number of entities
+func (w *World) Len() int {
+	return len(w.entities)
+}
+
+//Add adds the components to an entity, replacing any it already has of the same type.
+//Nothing is added to an entity that is not in the World.
+func (w *World) Add(id xid.ID, components ...interface{}) {
+	if !w.Alive(id) {
+		return
+	}
+	for _, c := range components {
+		if c == nil {
+			continue
+		}
+		t := reflect.TypeOf(c)
+		s, ok := w.stores[t]
+		if !ok {
+			s = &componentStore{index: map[xid.ID]int{}}
+			w.stores[t] = s
+		}
+		s.set(id, c)
+	}
+}
+
+//Remove removes the components of the types of the examples, such as (*Velocity)(nil), from the entity
+func (w *World) Remove(id xid.ID, examples ...interface{}) {
+	for _, ex := range examples {
+		if s, ok := w.stores[reflect.TypeOf(ex)]; ok {
+			s.remove(id)
+		}
+	}
+}
+
+//Has returns true if the entity has components of the types of all the examples
+func (w *World) Has(id xid.ID, examples ...interface{}) bool {
+	for _, ex := range examples {
+		s, ok := w.stores[reflect.TypeOf(ex)]
+		if !ok {
+			return false
+		}
+		if _, ok := s.index[id]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+//Get sets target, a pointer to a variable of a component type, to the component of that type of the entity.
+//It returns false and leaves target alone if the entity has no such component or target is not a non-nil pointer.
+//
+//	var pos *Position
+//	if w.Get(id, &pos) { pos.X++ }
+func (w *World) Get(id xid.ID, target interface{}) bool {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return false
+	}
+	s, ok := w.stores[v.Type().Elem()]
+	if !ok {
+		return false
+	}
+	i, ok := s.index[id]
+	if !ok {
+		return false
+	}
+	v.Elem().Set(reflect.ValueOf(s.components[i]))
+	return true
+}
+
+//Component returns the component of the type of the example, such as (*Position)(nil), of the entity or nil
+func (w *World) Component(id xid.ID, example interface{}) interface{} {
+	s, ok := w.stores[reflect.TypeOf(example)]
+	if !ok {
+		return nil
+	}
+	if i, ok := s.index[id]; ok {
+		return s.components[i]
+	}
+	return nil
+}
+
+//Query returns the entities with components of the types of all the examples, such as
+//w.Query((*Position)(nil), (*Velocity)(nil)), in the order the entities were added.
+//The result is a copy, so entities can be changed or removed while walking it.
+func (w *World) Query(examples ...interface{}) []xid.ID {
+	stores := make([]*componentStore, 0, len(examples))
+	for _, ex := range examples {
+		s, ok := w.stores[reflect.TypeOf(ex)]
+		if !ok {
+			return nil
+		}
+		stores = append(stores, s)
+	}
+	if len(stores) == 0 {
+		return w.Entities()
+	}
+	//Walk the smallest store and keep the entities found in the others
+	sort.Slice(stores, func(i, j int) bool { return len(stores[i].entities) < len(stores[j].entities) })
+	var found []xid.ID
+	for _, id := range stores[0].entities {
+		if hasAll(stores[1:], id) {
+			found = append(found, id)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return w.entityIndex[found[i]] < w.entityIndex[found[j]] })
+	return found
+}
+
+//Each calls fn with every entity returned by Query for the examples, stopping at the first error
+func (w *World) Each(fn func(id xid.ID) error, examples ...interface{}) error {
+	for _, id := range w.Query(examples...) {
+		if !w.Alive(id) {
+			continue
+		}
+		if err := fn(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasAll(stores []*componentStore, id xid.ID) bool {
+	for _, s := range stores {
+		if _, ok := s.index[id]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *componentStore) set(id xid.ID, c interface{}) {
+	if i, ok := s.index[id]; ok {
+		s.components[i] = c
+		return
+	}
+	s.index[id] = len(s.entities)
+	s.entities = append(s.entities, id)
+	s.components = append(s.components, c)
+}
+
+//remove swaps the last component into the place of the removed one so the store stays packed
+func (s *componentStore) remove(id xid.ID) {
+	i, ok := s.index[id]
+	if !ok {
+		return
+	}
+	last := len(s.entities) - 1
+	s.entities[i], s.components[i] = s.entities[last], s.components[last]
+	s.index[s.entities[i]] = i
+	s.components[last] = nil
+	s.entities = s.entities[:last]
+	s.components = s.components[:last]
+	delete(s.index, id)
+}
+
+//AddSystem adds the system under the name, replacing any system with that name.
+//Systems run from the lowest order to the highest, those with the same order in the order they were added.
+func (w *World) AddSystem(name string, order int, s System) {
+	w.RemoveSystem(name)
+	w.systems = append(w.systems, worldSystem{name: name, order: order, added: w.nextSystem, system: s})
+	w.nextSystem++
+	sort.Slice(w.systems, func(i, j int) bool {
+		if w.systems[i].order != w.systems[j].order {
+			return w.systems[i].order < w.systems[j].order
+		}
+		return w.systems[i].added < w.systems[j].added
+	})
+}
+
+//RemoveSystem removes the named system
+func (w *World) RemoveSystem(name string) {
+	for i, s := range w.systems {
+		if s.name == name {
+			w.systems = append(w.systems[:i], w.systems[i+1:]...)
+			return
+		}
+	}
+}
+
+//System returns the named system, nil if there is none
+func (w *World) System(name string) System {
+	for _, s := range w.systems {
+		if s.name == name {
+			return s.system
+		}
+	}
+	return nil
+}
+
+//Systems returns the names of the systems in the order they run
+func (w *World) Systems() []string {
+	names := make([]string, len(w.systems))
+	for i, s := range w.systems {
+		names[i] = s.name
+	}
+	return names
+}
+
+//Update runs every system in order, stopping at the first error
+func (w *World) Update(g *Game) error {
+	for _, s := range append([]worldSystem(nil), w.systems...) {
+		if err := s.system.Update(w, g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Draw runs every DrawSystem in order, stopping at the first error
+func (w *World) Draw(g *Game) error {
+	for _, s := range w.systems {
+		if ds, ok := s.system.(DrawSystem); ok {
+			if err := ds.Draw(w, g); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//DrawObjects returns a DrawSystem drawing every object added with AddObject that is a GameObject on Game.Screen,
+//in the order they were added
+func DrawObjects() DrawSystem {
+	return DrawSystemFunc(func(w *World, g *Game) error {
+		for _, id := range w.entities {
+			if obj, ok := w.objects[id].(GameObject); ok {
+				if err := obj.Draw(g.Screen); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+//MoveObjects returns a System moving every BasicObject in the World by its velocity each step
+func MoveObjects() System {
+	return SystemFunc(func(w *World, g *Game) error {
+		return w.Each(func(id xid.ID) error {
+			var obj *BasicObject
+			w.Get(id, &obj)
+			obj.AddPosition(obj.GetVelocity())
+			return nil
+		}, (*BasicObject)(nil))
+	})
+}
+
+//WorldState is a GameState updating and drawing a World
+type WorldState struct {
+	*BaseGameState
+	World *World
+}
+
+//NewWorldState returns a GameState updating the World every fixed step and drawing it every frame
+func NewWorldState(w *World) *WorldState {
+	return &WorldState{
+		BaseGameState: NewBaseGameState(),
+		World:         w,
+	}
+}
+
+//Update runs the systems of the World
+func (s *WorldState) Update(g *Game) error {
+	return s.World.Update(g)
+}
+
+//Draw runs the draw systems of the World
+func (s *WorldState) Draw(g *Game) error {
+	return s.World.Draw(g)
+}